@@ -1,11 +1,12 @@
 module github.com/blakewilliams/remote-development-manager
 
-go 1.17
+go 1.20
 
 require (
 	github.com/brasic/launchd v1.0.3
 	github.com/spf13/cobra v1.3.0
 	github.com/stretchr/testify v1.8.1
+	golang.org/x/sys v0.1.0
 )
 
 require (
@@ -16,6 +17,5 @@ require (
 	github.com/mattn/go-isatty v0.0.14 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
-	golang.org/x/sys v0.1.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
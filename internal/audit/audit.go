@@ -0,0 +1,159 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blakewilliams/remote-development-manager/internal/client"
+	"github.com/blakewilliams/remote-development-manager/internal/handler"
+)
+
+// Record is a single action taken by the server on behalf of a client.
+type Record struct {
+	Time   time.Time       `json:"time"`
+	Client client.Identity `json:"client"`
+	// Peer is the local process connected to the server's socket.
+	Peer    *handler.Peer `json:"peer,omitempty"`
+	Command string        `json:"command"`
+	// Target describes what the command acted on, such as a URL or the
+	// number of bytes copied. It never includes clipboard contents.
+	Target string `json:"target,omitempty"`
+	// Result is "ok" or the error code of a failed command.
+	Result string `json:"result"`
+	Error  string `json:"error,omitempty"`
+	// ResponseBytes is the size of the response sent to the client.
+	ResponseBytes int `json:"response_bytes"`
+	// Content is only recorded when explicitly configured.
+	Content string `json:"content,omitempty"`
+}
+
+// Log is an append-only audit log of JSON records, one per line. It is safe
+// for concurrent use.
+type Log struct {
+	mu             sync.Mutex
+	file           *os.File
+	includeContent bool
+}
+
+// Open opens the audit log at path for appending, creating it if needed with
+// permissions only allowing access by the current user. When includeContent
+// is false the Content of records is never written.
+func Open(path string, includeContent bool) (*Log, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("could not open audit log %s: %w", path, err)
+	}
+
+	return &Log{file: file, includeContent: includeContent}, nil
+}
+
+// Write appends the record to the log.
+func (l *Log) Write(record Record) error {
+	if !l.includeContent {
+		record.Content = ""
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("could not encode audit record: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, err := l.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("could not write audit record: %w", err)
+	}
+
+	return nil
+}
+
+// Close closes the underlying file.
+func (l *Log) Close() error {
+	return l.file.Close()
+}
+
+// Filter selects records from the audit log. Empty fields match every record.
+type Filter struct {
+	Command string
+	// Session matches the session ID, label, hostname or user of the client.
+	Session string
+	Since   time.Time
+	// Failed only matches records of commands that failed.
+	Failed bool
+}
+
+// Matches returns true if the record satisfies every field of the filter.
+func (f Filter) Matches(record Record) bool {
+	if f.Command != "" && record.Command != f.Command {
+		return false
+	}
+
+	if f.Session != "" {
+		switch f.Session {
+		case record.Client.SessionID, record.Client.Label, record.Client.Hostname, record.Client.User:
+		default:
+			return false
+		}
+	}
+
+	if !f.Since.IsZero() && record.Time.Before(f.Since) {
+		return false
+	}
+
+	if f.Failed && record.Result == "ok" {
+		return false
+	}
+
+	return true
+}
+
+// Read returns the records in r matching the filter. Lines that can't be
+// parsed are skipped.
+func Read(r io.Reader, filter Filter) ([]Record, error) {
+	var records []Record
+
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString('\n')
+		if strings.TrimSpace(line) != "" {
+			var record Record
+			if json.Unmarshal([]byte(line), &record) == nil && filter.Matches(record) {
+				records = append(records, record)
+			}
+		}
+
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read audit log: %w", err)
+		}
+	}
+}
+
+func (r Record) String() string {
+	var description strings.Builder
+	fmt.Fprintf(&description, "%s %s", r.Time.Local().Format(time.RFC3339), r.Client)
+	if r.Peer != nil {
+		fmt.Fprintf(&description, " (pid %d)", r.Peer.PID)
+	}
+	fmt.Fprintf(&description, " %s", r.Command)
+
+	if r.Target != "" {
+		fmt.Fprintf(&description, " %s", r.Target)
+	}
+
+	fmt.Fprintf(&description, " -> %s", r.Result)
+	if r.Error != "" {
+		fmt.Fprintf(&description, " (%s)", r.Error)
+	}
+
+	return description.String()
+}
@@ -0,0 +1,63 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/blakewilliams/remote-development-manager/internal/client"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	start := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+	foo := client.Identity{Hostname: "foo", User: "blake", SessionID: "1", Label: "codespace-foo"}
+	bar := client.Identity{Hostname: "bar", User: "blake", SessionID: "2"}
+
+	log, err := Open(path, false)
+	require.NoError(t, err)
+
+	require.NoError(t, log.Write(Record{Time: start, Client: foo, Command: "copy", Target: "6 bytes", Result: "ok", Content: "secret"}))
+	require.NoError(t, log.Write(Record{Time: start.Add(time.Minute), Client: bar, Command: "open", Target: "https://github.com", Result: "ok"}))
+	require.NoError(t, log.Write(Record{Time: start.Add(2 * time.Minute), Client: foo, Command: "open", Target: "https://example.com", Result: "failed", Error: "xdg-open not found"}))
+	require.NoError(t, log.Close())
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	records, err := Read(file, Filter{})
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	require.Empty(t, records[0].Content)
+	require.Equal(t, foo, records[0].Client)
+
+	testCases := map[string]struct {
+		filter   Filter
+		expected []string
+	}{
+		"command": {filter: Filter{Command: "open"}, expected: []string{"https://github.com", "https://example.com"}},
+		"label":   {filter: Filter{Session: "codespace-foo"}, expected: []string{"6 bytes", "https://example.com"}},
+		"host":    {filter: Filter{Session: "bar"}, expected: []string{"https://github.com"}},
+		"since":   {filter: Filter{Since: start.Add(time.Minute)}, expected: []string{"https://github.com", "https://example.com"}},
+		"failed":  {filter: Filter{Failed: true}, expected: []string{"https://example.com"}},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var targets []string
+			for _, record := range records {
+				if tc.filter.Matches(record) {
+					targets = append(targets, record.Target)
+				}
+			}
+
+			require.Equal(t, tc.expected, targets)
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// ProtocolVersion is the version of the protocol spoken between the client
+// and the server. It should be incremented whenever a command or option is
+// added so that older servers can be detected.
+const ProtocolVersion = 7
+
+// Capabilities describes the protocol version and commands supported by a
+// server. Commands maps each command name to the options it accepts.
+type Capabilities struct {
+	Version  int                 `json:"version"`
+	Commands map[string][]string `json:"commands"`
+}
+
+// legacyCapabilities are assumed for servers that predate protocol
+// versioning. Those servers silently ignore the capabilities command.
+var legacyCapabilities = Capabilities{
+	Version: 0,
+	Commands: map[string][]string{
+		"status": {},
+		"copy":   {},
+		"paste":  {},
+		"open":   {},
+		"stop":   {},
+	},
+}
+
+// Supports returns true if the server understands the given command.
+func (c *Capabilities) Supports(command string) bool {
+	_, ok := c.Commands[command]
+	return ok
+}
+
+// SupportsOption returns true if the server understands the given option for
+// the given command.
+func (c *Capabilities) SupportsOption(command string, option string) bool {
+	for _, supported := range c.Commands[command] {
+		if supported == option {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CommandNames returns the supported command names in sorted order.
+func (c *Capabilities) CommandNames() []string {
+	names := make([]string, 0, len(c.Commands))
+	for name := range c.Commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// UnsupportedError is returned when the server does not support a command or
+// option that the client attempted to use.
+type UnsupportedError struct {
+	Command       string
+	Option        string
+	ServerVersion int
+}
+
+func (e *UnsupportedError) Error() string {
+	feature := fmt.Sprintf("command %q", e.Command)
+	if e.Option != "" {
+		feature = fmt.Sprintf("option %q for command %q", e.Option, e.Command)
+	}
+
+	return fmt.Sprintf(
+		"the rdm server (protocol v%d) does not support %s, upgrade rdm on the host machine to protocol v%d or later",
+		e.ServerVersion,
+		feature,
+		ProtocolVersion,
+	)
+}
+
+// Capabilities returns the capabilities of the server, falling back to the
+// legacy set of commands when the server predates protocol versioning. The
+// result is cached for the lifetime of the client.
+func (c *Client) Capabilities(ctx context.Context) (*Capabilities, error) {
+	c.capabilitiesMu.Lock()
+	defer c.capabilitiesMu.Unlock()
+
+	if c.capabilities != nil {
+		return c.capabilities, nil
+	}
+
+	contents, err := c.send(ctx, Command{Name: "capabilities", Version: ProtocolVersion})
+	if err != nil {
+		return nil, err
+	}
+
+	capabilities := legacyCapabilities
+	if len(contents) > 0 {
+		capabilities = Capabilities{}
+		if err := json.Unmarshal(contents, &capabilities); err != nil {
+			return nil, fmt.Errorf("could not parse server capabilities: %w", err)
+		}
+	}
+
+	c.capabilities = &capabilities
+	return c.capabilities, nil
+}
+
+// checkCapabilities returns an UnsupportedError if the server can not handle
+// the given command. Commands that every server understands skip the check to
+// avoid an additional round-trip.
+func (c *Client) checkCapabilities(ctx context.Context, command Command) error {
+	if legacyCapabilities.Supports(command.Name) && len(command.Options) == 0 {
+		return nil
+	}
+
+	capabilities, err := c.Capabilities(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !capabilities.Supports(command.Name) {
+		return &UnsupportedError{Command: command.Name, ServerVersion: capabilities.Version}
+	}
+
+	for option := range command.Options {
+		if !capabilities.SupportsOption(command.Name, option) {
+			return &UnsupportedError{Command: command.Name, Option: option, ServerVersion: capabilities.Version}
+		}
+	}
+
+	return nil
+}
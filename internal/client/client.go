@@ -4,12 +4,13 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
-	"os"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -18,24 +19,96 @@ type Client struct {
 	// should be forwarded via ssh
 	path       string
 	httpClient http.Client
+	// capabilitiesMu guards capabilities, which are fetched once and shared by
+	// concurrent commands.
+	capabilitiesMu sync.Mutex
+	capabilities   *Capabilities
+	identity       Identity
+	// timeout limits how long a command may take, including any time spent
+	// waiting on the user at the host.
+	timeout time.Duration
 }
 
 type Command struct {
 	Name      string
 	Arguments []string
+	Options   map[string]string `json:",omitempty"`
+	// Client identifies the machine and session that sent the command.
+	Client *Identity `json:",omitempty"`
+	// Version is the protocol version of the client. Servers treat a missing
+	// version as a legacy client and never respond with an error status.
+	Version int `json:",omitempty"`
 }
 
-func UnixSocketPath() string {
-	return strings.TrimRight(os.TempDir(), "/") + "/rdm.sock"
+// ServerError is returned when the server responds with an error status.
+type ServerError struct {
+	StatusCode int
+	// Code categorizes the error, e.g. "not_found" or "invalid_argument".
+	Code    string
+	Message string
+}
+
+func (e *ServerError) Error() string {
+	return fmt.Sprintf("server responded with %d: %s", e.StatusCode, e.Message)
+}
+
+// ErrRateLimited matches server errors for commands that were sent too often,
+// using errors.Is.
+var ErrRateLimited = errors.New("rate limited")
+
+// ErrTimeout matches server errors for commands that did not finish on the
+// host in time, using errors.Is.
+var ErrTimeout = errors.New("timed out")
+
+func (e *ServerError) Is(target error) bool {
+	switch target {
+	case ErrRateLimited:
+		return e.Code == "rate_limited"
+	case ErrTimeout:
+		return e.Code == "timeout"
+	default:
+		return false
+	}
 }
 
 func (c *Client) SendCommand(ctx context.Context, commandName string, arguments ...string) ([]byte, error) {
-	command := Command{
+	return c.Send(ctx, Command{
 		Name:      commandName,
 		Arguments: arguments,
+	})
+}
+
+// Send sends the command to the server, first verifying that the server
+// supports the command and its options.
+func (c *Client) Send(ctx context.Context, command Command) ([]byte, error) {
+	command.Version = ProtocolVersion
+	if !c.identity.IsZero() {
+		command.Client = &c.identity
+	}
+
+	if err := c.checkCapabilities(ctx, command); err != nil {
+		return nil, err
+	}
+
+	return c.send(ctx, command)
+}
+
+// SetTimeout changes how long commands may take before they are cancelled.
+func (c *Client) SetTimeout(timeout time.Duration) {
+	c.timeout = timeout
+}
+
+func (c *Client) send(ctx context.Context, command Command) ([]byte, error) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
 	}
 
 	result, err := json.Marshal(command)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode command: %w", err)
+	}
 	reader := bytes.NewReader(result)
 
 	request, err := http.NewRequestWithContext(ctx, http.MethodPost, c.path, reader)
@@ -56,43 +129,49 @@ func (c *Client) SendCommand(ctx context.Context, commandName string, arguments
 		return nil, fmt.Errorf("could not read response from server: %w", err)
 	}
 
+	if response.StatusCode >= http.StatusBadRequest {
+		var body struct {
+			Error string `json:"error"`
+			Code  string `json:"code"`
+		}
+		if err := json.Unmarshal(contents, &body); err != nil || body.Error == "" {
+			body.Error = strings.TrimSpace(string(contents))
+		}
+
+		return nil, &ServerError{StatusCode: response.StatusCode, Code: body.Code, Message: body.Error}
+	}
+
 	return contents, nil
 }
 
-const (
-	RunLocal  = "unix"
-	RunRemote = "tcp"
-)
-
+// New returns a client for the server found by DiscoverTransport, which is
+// the local server unless RDM_ADDR is set or the server was forwarded.
 func New() *Client {
-	return NewWithSocketPath(UnixSocketPath())
+	return NewWithTransport(DiscoverTransport(UnixSocketPath()).Transport)
 }
 
+// NewWithSocketPath returns a client for the server listening on socketPath.
 func NewWithSocketPath(socketPath string) *Client {
-	runType := RunLocal
-
-	if os.Getenv("SSH_TTY") != "" || os.Getenv("SSH_CLIENT") != "" || os.Getenv("SSH_CONNECTION") != "" {
-		runType = RunRemote
-	}
+	return NewWithTransport(Transport{Network: NetworkUnix, Address: socketPath})
+}
 
+// NewWithTransport returns a client connecting to the server over transport.
+func NewWithTransport(transport Transport) *Client {
 	client := &Client{
-		httpClient: http.Client{
-			Timeout: time.Second * 10,
-		},
+		identity: CurrentIdentity(),
+		timeout:  time.Second * 10,
 	}
 
-	if runType == RunLocal {
-		client.path = "http://unix://" + socketPath
-	} else {
-		client.path = "http://localhost:7391"
+	if transport.Network == NetworkTCP {
+		client.path = "http://" + transport.Address
+		return client
 	}
 
-	if runType == RunLocal {
-		client.httpClient.Transport = &http.Transport{
-			DialContext: func(_ctx context.Context, _network string, _address string) (net.Conn, error) {
-				return net.Dial("unix", socketPath)
-			},
-		}
+	client.path = "http://unix://" + transport.Address
+	client.httpClient.Transport = &http.Transport{
+		DialContext: func(_ctx context.Context, _network string, _address string) (net.Conn, error) {
+			return net.Dial("unix", transport.Address)
+		},
 	}
 
 	return client
@@ -3,27 +3,34 @@ package client
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"regexp"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 )
 
-func TestClient_path(t *testing.T) {
-	client := New()
+func TestNewWithTransport(t *testing.T) {
+	client := NewWithTransport(Transport{Network: NetworkUnix, Address: "/tmp/rdm.sock"})
 	require.Regexp(t, regexp.MustCompile("http://unix://"), client.path)
 
-	os.Setenv("SSH_TTY", "fake")
-	defer os.Unsetenv("SSH_TTY")
-
-	client = New()
+	client = NewWithTransport(Transport{Network: NetworkTCP, Address: DefaultTCPAddr})
 	require.Equal(t, "http://localhost:7391", client.path)
 }
 
+func TestNewWithSocketPath(t *testing.T) {
+	// The given socket is used even when discovery would pick another.
+	t.Setenv("RDM_ADDR", "tcp://127.0.0.1:1")
+
+	client := NewWithSocketPath("/tmp/rdm.sock")
+	require.Equal(t, "http://unix:///tmp/rdm.sock", client.path)
+}
+
 func TestClient_SendCommand(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
 		content, err := io.ReadAll(r.Body)
@@ -51,3 +58,126 @@ func TestClient_SendCommand(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, "test result", string(responseContent))
 }
+
+func TestClient_Send_LegacyServer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		// Legacy servers ignore unknown commands and respond with nothing.
+	}))
+	defer server.Close()
+
+	client := &Client{
+		path:       server.URL,
+		httpClient: *http.DefaultClient,
+	}
+
+	capabilities, err := client.Capabilities(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 0, capabilities.Version)
+	require.True(t, capabilities.Supports("copy"))
+
+	_, err = client.SendCommand(context.Background(), "sessions")
+
+	var unsupported *UnsupportedError
+	require.ErrorAs(t, err, &unsupported)
+	require.Equal(t, "sessions", unsupported.Command)
+	require.Contains(t, err.Error(), "upgrade rdm on the host machine")
+}
+
+func TestClient_Send_UnsupportedOption(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(rw).Encode(Capabilities{
+			Version:  ProtocolVersion,
+			Commands: map[string][]string{"copy": {}},
+		})
+	}))
+	defer server.Close()
+
+	client := &Client{
+		path:       server.URL,
+		httpClient: *http.DefaultClient,
+	}
+
+	_, err := client.Send(context.Background(), Command{
+		Name:      "copy",
+		Arguments: []string{"secret"},
+		Options:   map[string]string{"ttl": "30s"},
+	})
+
+	var unsupported *UnsupportedError
+	require.ErrorAs(t, err, &unsupported)
+	require.Equal(t, "ttl", unsupported.Option)
+}
+
+func TestClient_Send_ServerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusInternalServerError)
+		rw.Write([]byte(`{"error":"xclip not found"}`))
+	}))
+	defer server.Close()
+
+	client := &Client{
+		path:       server.URL,
+		httpClient: *http.DefaultClient,
+	}
+
+	_, err := client.SendCommand(context.Background(), "copy", "test")
+
+	var serverError *ServerError
+	require.ErrorAs(t, err, &serverError)
+	require.Equal(t, http.StatusInternalServerError, serverError.StatusCode)
+	require.Equal(t, "xclip not found", serverError.Message)
+}
+
+func TestClient_SendCommand_Identity(t *testing.T) {
+	t.Setenv("RDM_LABEL", "codespace-foo")
+
+	var command Command
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&command))
+	}))
+	defer server.Close()
+
+	client := New()
+	client.path = server.URL
+	client.httpClient = *http.DefaultClient
+
+	_, err := client.SendCommand(context.Background(), "copy", "test")
+	require.NoError(t, err)
+
+	require.NotNil(t, command.Client)
+	require.Equal(t, "codespace-foo", command.Client.Label)
+	require.Equal(t, "codespace-foo", command.Client.String())
+	require.NotEmpty(t, command.Client.SessionID)
+}
+
+func TestUnixSocketPath(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+
+	defer func(instance string) { Instance = instance }(Instance)
+
+	Instance = ""
+	require.Equal(t, "/run/user/1000/rdm/rdm.sock", UnixSocketPath())
+
+	Instance = "work"
+	require.Equal(t, "/run/user/1000/rdm/rdm-work.sock", UnixSocketPath())
+
+	t.Setenv("XDG_RUNTIME_DIR", "")
+	require.Equal(t, filepath.Join(os.TempDir(), fmt.Sprintf("rdm-%d", os.Getuid()), "rdm-work.sock"), UnixSocketPath())
+
+	require.NoError(t, ValidateInstance("work_2"))
+	require.Error(t, ValidateInstance("../work"))
+}
+
+func TestForwardedSocketPaths(t *testing.T) {
+	t.Setenv("RDM_FORWARDED_SOCKET", "")
+	t.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+	require.Equal(t, []string{"/run/user/1000/rdm-forwarded.sock", RemoteSocketPath(currentUsername())}, ForwardedSocketPaths())
+
+	t.Setenv("XDG_RUNTIME_DIR", "")
+	require.Equal(t, []string{RemoteSocketPath(currentUsername())}, ForwardedSocketPaths())
+
+	t.Setenv("RDM_FORWARDED_SOCKET", "/home/blake/rdm.sock")
+	require.Equal(t, []string{"/home/blake/rdm.sock"}, ForwardedSocketPaths())
+
+	require.Equal(t, "/tmp/rdm-forwarded-blake.sock", RemoteSocketPath("blake"))
+}
@@ -0,0 +1,74 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/user"
+)
+
+// Identity describes the machine and session a command was sent from so the
+// server can tell remote clients apart.
+type Identity struct {
+	Hostname  string `json:"hostname,omitempty"`
+	User      string `json:"user,omitempty"`
+	SessionID string `json:"session_id,omitempty"`
+	// Label is an optional human friendly name for the session, e.g. the
+	// name of a codespace.
+	Label string `json:"label,omitempty"`
+}
+
+// CurrentIdentity returns the identity of the current process. The session ID
+// can be set with RDM_SESSION_ID and the label with RDM_LABEL, which defaults
+// to the codespace name when running in Codespaces.
+func CurrentIdentity() Identity {
+	hostname, _ := os.Hostname()
+	username := currentUsername()
+
+	label := os.Getenv("RDM_LABEL")
+	if label == "" {
+		label = os.Getenv("CODESPACE_NAME")
+	}
+
+	sessionID := os.Getenv("RDM_SESSION_ID")
+	if sessionID == "" {
+		// SSH_CONNECTION is unique per ssh session, so commands sent from the
+		// same session share an ID without any coordination.
+		sum := sha256.Sum256([]byte(hostname + "\x00" + username + "\x00" + os.Getenv("SSH_CONNECTION")))
+		sessionID = hex.EncodeToString(sum[:6])
+	}
+
+	return Identity{
+		Hostname:  hostname,
+		User:      username,
+		SessionID: sessionID,
+		Label:     label,
+	}
+}
+
+// currentUsername returns the name of the user running the process.
+func currentUsername() string {
+	if current, err := user.Current(); err == nil {
+		return current.Username
+	}
+
+	return os.Getenv("USER")
+}
+
+// IsZero returns true for requests from clients that predate identities.
+func (i Identity) IsZero() bool {
+	return i == Identity{}
+}
+
+func (i Identity) String() string {
+	if i.IsZero() {
+		return "anonymous"
+	}
+
+	if i.Label != "" {
+		return i.Label
+	}
+
+	return fmt.Sprintf("%s@%s", i.User, i.Hostname)
+}
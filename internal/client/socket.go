@@ -0,0 +1,70 @@
+package client
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// Instance is the name of the server instance to use, allowing a user to run
+// several servers side by side. The default instance has an empty name.
+var Instance = os.Getenv("RDM_INSTANCE")
+
+var instancePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// ValidateInstance returns an error if name can't be used as an instance
+// name.
+func ValidateInstance(name string) error {
+	if name != "" && !instancePattern.MatchString(name) {
+		return fmt.Errorf("invalid instance %q, names may only contain letters, numbers, - and _", name)
+	}
+
+	return nil
+}
+
+// SocketDir returns the per-user directory holding server sockets. This is
+// $XDG_RUNTIME_DIR/rdm when set, or a directory named after the user's ID in
+// the temporary directory, which is already per-user on macOS.
+func SocketDir() string {
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		return filepath.Join(runtimeDir, "rdm")
+	}
+
+	return filepath.Join(os.TempDir(), fmt.Sprintf("rdm-%d", os.Getuid()))
+}
+
+// UnixSocketPath returns the path of the socket for the current Instance.
+func UnixSocketPath() string {
+	if Instance == "" {
+		return filepath.Join(SocketDir(), "rdm.sock")
+	}
+
+	return filepath.Join(SocketDir(), fmt.Sprintf("rdm-%s.sock", Instance))
+}
+
+// ForwardedSocketPaths returns where a server socket forwarded from the host
+// over SSH may be found on remote machines. This is RDM_FORWARDED_SOCKET when
+// set, otherwise rdm-forwarded.sock in $XDG_RUNTIME_DIR followed by
+// RemoteSocketPath for the current user.
+func ForwardedSocketPaths() []string {
+	if path := os.Getenv("RDM_FORWARDED_SOCKET"); path != "" {
+		return []string{path}
+	}
+
+	var paths []string
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		paths = append(paths, filepath.Join(runtimeDir, "rdm-forwarded.sock"))
+	}
+
+	return append(paths, RemoteSocketPath(currentUsername()))
+}
+
+// RemoteSocketPath returns the default path a socket is forwarded to for
+// username on remote machines. The host can't know the remote
+// $XDG_RUNTIME_DIR, so this is in /tmp. Since any user can create a file at
+// this path, clients only connect to sockets owned by themselves; sshd
+// creates forwarded sockets accessible only by their owner.
+func RemoteSocketPath(username string) string {
+	return fmt.Sprintf("/tmp/rdm-forwarded-%s.sock", username)
+}
@@ -0,0 +1,172 @@
+package client
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Networks the client can connect to the server over.
+const (
+	NetworkUnix = "unix"
+	NetworkTCP  = "tcp"
+)
+
+// DefaultTCPAddr is where remote machines usually reach the server, through a
+// port forwarded over SSH.
+const DefaultTCPAddr = "localhost:7391"
+
+// tcpProbeAddr is the address probed for a forwarded port. It is a variable so
+// tests don't depend on the port being free.
+var tcpProbeAddr = DefaultTCPAddr
+
+// probeTimeout bounds how long the forwarded port is probed for. Connections
+// to localhost are refused immediately when nothing is listening, so this
+// only matters for unusual setups.
+const probeTimeout = 250 * time.Millisecond
+
+// Transport is how the client connects to the server.
+type Transport struct {
+	// Network is NetworkUnix or NetworkTCP.
+	Network string
+	// Address is a socket path or a TCP host:port.
+	Address string
+	// Reason explains why the transport was chosen.
+	Reason string
+}
+
+func (t Transport) String() string {
+	return t.Network + ":" + t.Address
+}
+
+// ParseAddr parses a server address as given in RDM_ADDR: a unix socket path,
+// optionally prefixed with unix://, or a TCP host:port, optionally prefixed
+// with tcp://.
+func ParseAddr(addr string) (Transport, error) {
+	switch {
+	case strings.HasPrefix(addr, "unix://"):
+		addr = strings.TrimPrefix(addr, "unix://")
+		if addr == "" {
+			return Transport{}, fmt.Errorf("invalid address %q, missing socket path", "unix://")
+		}
+		return Transport{Network: NetworkUnix, Address: addr}, nil
+	case filepath.IsAbs(addr):
+		return Transport{Network: NetworkUnix, Address: addr}, nil
+	}
+
+	hostPort := strings.TrimPrefix(addr, "tcp://")
+	if _, _, err := net.SplitHostPort(hostPort); err != nil {
+		return Transport{}, fmt.Errorf("invalid address %q, expected a socket path or host:port: %w", addr, err)
+	}
+
+	return Transport{Network: NetworkTCP, Address: hostPort}, nil
+}
+
+// Discovery is the result of DiscoverTransport.
+type Discovery struct {
+	// Transport is the chosen transport.
+	Transport Transport
+	// Skipped explains why each candidate before the chosen one wasn't used.
+	Skipped []string
+}
+
+// DiscoverTransport picks how to reach the server rather than guessing from
+// SSH environment variables, which are missing in containers, mosh, sudo and
+// Codespaces. The candidates are, in order:
+//
+//  1. the address in RDM_ADDR, when set
+//  2. socketPath, when a socket exists there, e.g. on the host
+//  3. a socket forwarded over SSH, when one exists at ForwardedSocketPaths
+//  4. localhost:7391, when something is listening, e.g. a forwarded port
+//
+// Forwarded sockets are preferred over the port since they're only
+// accessible by the current user, while any user can connect to the port.
+// They're only used when owned by the current user and not writable by
+// others, since another user could create one at the default path first.
+//
+// When none are available the local socket is used, so errors name it.
+func DiscoverTransport(socketPath string) Discovery {
+	var discovery Discovery
+	skip := func(format string, args ...interface{}) {
+		discovery.Skipped = append(discovery.Skipped, fmt.Sprintf(format, args...))
+	}
+	choose := func(network, address, reason string) Discovery {
+		discovery.Transport = Transport{Network: network, Address: address, Reason: reason}
+		return discovery
+	}
+
+	if addr := os.Getenv("RDM_ADDR"); addr == "" {
+		skip("RDM_ADDR is not set")
+	} else if transport, err := ParseAddr(addr); err != nil {
+		skip("ignoring RDM_ADDR: %v", err)
+	} else {
+		return choose(transport.Network, transport.Address, "RDM_ADDR is set")
+	}
+
+	if err := checkSocket(socketPath); err != nil {
+		skip("local socket: %v", err)
+	} else {
+		return choose(NetworkUnix, socketPath, "the local server socket exists")
+	}
+
+	for _, forwarded := range ForwardedSocketPaths() {
+		if err := checkForwardedSocket(forwarded, os.Getuid()); err != nil {
+			skip("forwarded socket: %v", err)
+			continue
+		}
+
+		return choose(NetworkUnix, forwarded, "a socket was forwarded over SSH")
+	}
+
+	if conn, err := net.DialTimeout(NetworkTCP, tcpProbeAddr, probeTimeout); err != nil {
+		skip("nothing is listening on %s", tcpProbeAddr)
+	} else {
+		conn.Close()
+		return choose(NetworkTCP, tcpProbeAddr, fmt.Sprintf("a server is listening on %s, e.g. a port forwarded over SSH", tcpProbeAddr))
+	}
+
+	return choose(NetworkUnix, socketPath, "no server was found, falling back to the local socket")
+}
+
+// checkSocket returns an error unless path is a unix socket.
+func checkSocket(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("%s does not exist", path)
+	}
+
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s is not a socket", path)
+	}
+
+	return nil
+}
+
+// checkForwardedSocket returns an error unless path is a unix socket owned by
+// uid that other users can't connect to. The path itself isn't followed if
+// it's a symlink.
+func checkForwardedSocket(path string, uid int) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return fmt.Errorf("%s does not exist", path)
+	}
+
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s is not a socket", path)
+	}
+
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || int(stat.Uid) != uid {
+		return fmt.Errorf("%s is not owned by the current user", path)
+	}
+
+	if info.Mode().Perm()&0022 != 0 {
+		return fmt.Errorf("%s is writable by other users", path)
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package client
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAddr(t *testing.T) {
+	testCases := map[string]Transport{
+		"/run/rdm.sock":        {Network: NetworkUnix, Address: "/run/rdm.sock"},
+		"unix:///run/rdm.sock": {Network: NetworkUnix, Address: "/run/rdm.sock"},
+		"localhost:7391":       {Network: NetworkTCP, Address: "localhost:7391"},
+		"tcp://127.0.0.1:8000": {Network: NetworkTCP, Address: "127.0.0.1:8000"},
+	}
+
+	for addr, expected := range testCases {
+		t.Run(addr, func(t *testing.T) {
+			transport, err := ParseAddr(addr)
+			require.NoError(t, err)
+			require.Equal(t, expected, transport)
+		})
+	}
+
+	for _, addr := range []string{"localhost", "unix://", "rdm.sock"} {
+		_, err := ParseAddr(addr)
+		require.Error(t, err, addr)
+	}
+}
+
+// useProbeAddr points the TCP probe at addr for the duration of the test.
+func useProbeAddr(t *testing.T, addr string) {
+	previous := tcpProbeAddr
+	tcpProbeAddr = addr
+	t.Cleanup(func() { tcpProbeAddr = previous })
+}
+
+// closedAddr returns an address nothing is listening on.
+func closedAddr(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	listener.Close()
+
+	return listener.Addr().String()
+}
+
+func listenUnix(t *testing.T, path string) {
+	listener, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+}
+
+func TestDiscoverTransport(t *testing.T) {
+	dir := t.TempDir()
+	forwarded := filepath.Join(dir, "forwarded.sock")
+	t.Setenv("RDM_FORWARDED_SOCKET", forwarded)
+	t.Setenv("RDM_ADDR", "")
+	useProbeAddr(t, closedAddr(t))
+
+	socketPath := filepath.Join(dir, "rdm.sock")
+
+	discovery := DiscoverTransport(socketPath)
+	require.Equal(t, Transport{Network: NetworkUnix, Address: socketPath, Reason: "no server was found, falling back to the local socket"}, discovery.Transport)
+	require.Len(t, discovery.Skipped, 4)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	useProbeAddr(t, listener.Addr().String())
+
+	discovery = DiscoverTransport(socketPath)
+	require.Equal(t, NetworkTCP, discovery.Transport.Network)
+	require.Equal(t, listener.Addr().String(), discovery.Transport.Address)
+
+	// Forwarded sockets are preferred over the port. sshd creates them
+	// accessible only by their owner.
+	listenUnix(t, forwarded)
+	require.NoError(t, os.Chmod(forwarded, 0600))
+	discovery = DiscoverTransport(socketPath)
+	require.Equal(t, Transport{Network: NetworkUnix, Address: forwarded, Reason: "a socket was forwarded over SSH"}, discovery.Transport)
+
+	listenUnix(t, socketPath)
+	discovery = DiscoverTransport(socketPath)
+	require.Equal(t, socketPath, discovery.Transport.Address)
+	require.Equal(t, []string{"RDM_ADDR is not set"}, discovery.Skipped)
+
+	t.Setenv("RDM_ADDR", "tcp://localhost:8000")
+	discovery = DiscoverTransport(socketPath)
+	require.Equal(t, Transport{Network: NetworkTCP, Address: "localhost:8000", Reason: "RDM_ADDR is set"}, discovery.Transport)
+	require.Empty(t, discovery.Skipped)
+}
+
+func TestDiscoverTransport_NotSocket(t *testing.T) {
+	t.Setenv("RDM_ADDR", "")
+	useProbeAddr(t, closedAddr(t))
+
+	path := filepath.Join(t.TempDir(), "rdm.sock")
+	require.NoError(t, os.WriteFile(path, nil, 0600))
+
+	discovery := DiscoverTransport(path)
+	require.Contains(t, discovery.Skipped, "local socket: "+path+" is not a socket")
+}
+
+func TestCheckForwardedSocket(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "forwarded.sock")
+
+	require.EqualError(t, checkForwardedSocket(path, os.Getuid()), path+" does not exist")
+
+	listenUnix(t, path)
+	require.NoError(t, os.Chmod(path, 0600))
+	require.NoError(t, checkForwardedSocket(path, os.Getuid()))
+
+	// Sockets created by other users at the same path are ignored.
+	require.EqualError(t, checkForwardedSocket(path, os.Getuid()+1), path+" is not owned by the current user")
+
+	require.NoError(t, os.Chmod(path, 0620))
+	require.EqualError(t, checkForwardedSocket(path, os.Getuid()), path+" is writable by other users")
+
+	// Symlinks aren't followed.
+	link := filepath.Join(dir, "link.sock")
+	require.NoError(t, os.Symlink(path, link))
+	require.EqualError(t, checkForwardedSocket(link, os.Getuid()), link+" is not a socket")
+}
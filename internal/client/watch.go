@@ -0,0 +1,115 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/blakewilliams/remote-development-manager/internal/events"
+)
+
+// Watch streams events pushed by the server, calling fn for each one. Only
+// events of the given types are sent, or every event when types is empty.
+// Watch returns when ctx is done, the server closes the stream or fn returns
+// an error.
+func (c *Client) Watch(ctx context.Context, types []string, fn func(events.Event) error) error {
+	capabilities, err := c.Capabilities(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !capabilities.Supports("events") {
+		return &UnsupportedError{Command: "events", ServerVersion: capabilities.Version}
+	}
+
+	query := url.Values{}
+	if len(types) > 0 {
+		query.Set("types", strings.Join(types, ","))
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, c.endpoint("/v1/events")+"?"+query.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("could not create http request: %w", err)
+	}
+	request.Header.Set("Accept", "text/event-stream")
+	c.setIdentityHeaders(request)
+
+	// The stream stays open indefinitely, so the usual command timeout is
+	// not applied.
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("could not subscribe to events: %w", err)
+	}
+	defer response.Body.Close()
+
+	mediaType, _, _ := mime.ParseMediaType(response.Header.Get("Content-Type"))
+	if response.StatusCode != http.StatusOK || mediaType != "text/event-stream" {
+		return fmt.Errorf("could not subscribe to events: server responded with %d %s", response.StatusCode, mediaType)
+	}
+
+	return readEvents(response.Body, fn)
+}
+
+// readEvents parses a server-sent event stream, calling fn for each event.
+func readEvents(r io.Reader, fn func(events.Event) error) error {
+	reader := bufio.NewReader(r)
+	var data strings.Builder
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("could not read event stream: %w", err)
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+
+		switch {
+		case line == "":
+			if data.Len() == 0 {
+				continue
+			}
+
+			var event events.Event
+			if err := json.Unmarshal([]byte(data.String()), &event); err != nil {
+				return fmt.Errorf("could not parse event: %w", err)
+			}
+			data.Reset()
+
+			if err := fn(event); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "data:"):
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+}
+
+// endpoint returns the URL for a versioned route. Requests over a unix socket
+// use a placeholder host since the transport dials the socket directly.
+func (c *Client) endpoint(route string) string {
+	if strings.HasPrefix(c.path, "http://unix://") {
+		return "http://rdm" + route
+	}
+
+	return strings.TrimRight(c.path, "/") + route
+}
+
+// setIdentityHeaders identifies the client on requests to versioned routes.
+func (c *Client) setIdentityHeaders(request *http.Request) {
+	request.Header.Set("X-Rdm-Hostname", c.identity.Hostname)
+	request.Header.Set("X-Rdm-User", c.identity.User)
+	request.Header.Set("X-Rdm-Session", c.identity.SessionID)
+	request.Header.Set("X-Rdm-Label", c.identity.Label)
+}
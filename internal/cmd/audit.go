@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/blakewilliams/remote-development-manager/internal/audit"
+	"github.com/blakewilliams/remote-development-manager/internal/config"
+	"github.com/spf13/cobra"
+)
+
+func newAuditCmd(ctx context.Context, logger *log.Logger) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Inspects the log of actions the server took on behalf of remote clients",
+	}
+
+	cmd.AddCommand(newAuditTailCmd(ctx, logger))
+
+	return cmd
+}
+
+func newAuditTailCmd(ctx context.Context, logger *log.Logger) *cobra.Command {
+	var (
+		lines     int
+		filter    audit.Filter
+		since     string
+		follow    bool
+		printJSON bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "tail",
+		Short: "Prints the most recent entries of the audit log",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			if since != "" {
+				start, err := parseSince(since, time.Now())
+				if err != nil {
+					return invalidInput(err)
+				}
+				filter.Since = start
+			}
+
+			cfg, err := config.Load(config.Path())
+			if err != nil {
+				return fmt.Errorf("can not read audit log: %w", err)
+			}
+
+			path, err := cfg.AuditPath()
+			if err != nil {
+				return fmt.Errorf("can not read audit log: %w", err)
+			}
+
+			file, err := os.Open(path)
+			if err != nil {
+				return fmt.Errorf("can not read audit log: %w", err)
+			}
+			defer file.Close()
+
+			records, err := audit.Read(file, filter)
+			if err != nil {
+				return fmt.Errorf("can not read audit log: %w", err)
+			}
+
+			if lines >= 0 && len(records) > lines {
+				records = records[len(records)-lines:]
+			}
+
+			print := func(record audit.Record) {
+				if printJSON {
+					json.NewEncoder(os.Stdout).Encode(record)
+				} else {
+					fmt.Println(record)
+				}
+			}
+
+			for _, record := range records {
+				print(record)
+			}
+
+			if !follow {
+				return nil
+			}
+
+			err = followAudit(ctx, file, filter, print)
+			if err != nil && !errors.Is(err, context.Canceled) {
+				return fmt.Errorf("can not read audit log: %w", err)
+			}
+
+			return nil
+		},
+	}
+
+	addQuietFlag(cmd)
+
+	cmd.Flags().IntVarP(&lines, "lines", "n", 20, "number of entries to print, or -1 for all")
+	cmd.Flags().StringVar(&filter.Command, "command", "", "only print entries for the given command")
+	cmd.Flags().StringVar(&filter.Session, "session", "", "only print entries from a session ID, label, hostname or user")
+	cmd.Flags().StringVar(&since, "since", "", "only print entries after a time, e.g. 1h or 2022-01-01T12:00:00Z")
+	cmd.Flags().BoolVar(&filter.Failed, "failed", false, "only print entries for commands that failed")
+	cmd.Flags().BoolVarP(&follow, "follow", "f", false, "keep printing entries as they are written")
+	cmd.Flags().BoolVar(&printJSON, "json", false, "print entries as JSON lines")
+
+	return cmd
+}
+
+// parseSince parses a duration before now or an RFC 3339 timestamp.
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if duration, err := time.ParseDuration(value); err == nil {
+		return now.Add(-duration), nil
+	}
+
+	start, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --since %q, expected a duration or RFC 3339 time", value)
+	}
+
+	return start, nil
+}
+
+// followAudit polls the audit log for records appended after the current
+// offset of file until ctx is done.
+func followAudit(ctx context.Context, file *os.File, filter audit.Filter, fn func(audit.Record)) error {
+	reader := bufio.NewReader(file)
+	var partial strings.Builder
+
+	for {
+		line, err := reader.ReadString('\n')
+		partial.WriteString(line)
+
+		if err == io.EOF {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		var record audit.Record
+		if json.Unmarshal([]byte(partial.String()), &record) == nil && filter.Matches(record) {
+			fn(record)
+		}
+		partial.Reset()
+	}
+}
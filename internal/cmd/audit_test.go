@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	start, err := parseSince("90m", now)
+	require.NoError(t, err)
+	require.Equal(t, now.Add(-90*time.Minute), start)
+
+	start, err = parseSince("2021-12-31T08:00:00Z", now)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2021, 12, 31, 8, 0, 0, 0, time.UTC), start)
+
+	_, err = parseSince("yesterday", now)
+	require.Error(t, err)
+}
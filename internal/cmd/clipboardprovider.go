@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/blakewilliams/remote-development-manager/internal/client"
+	"github.com/blakewilliams/remote-development-manager/internal/handler/builtin"
+	"github.com/spf13/cobra"
+)
+
+// providerPaste is the output of `rdm clipboard-provider paste`, matching the
+// value Neovim expects from a clipboard provider's paste function.
+type providerPaste struct {
+	Lines        []string `json:"lines"`
+	RegisterType string   `json:"regtype"`
+}
+
+func newClipboardProviderCmd(ctx context.Context, logger *log.Logger) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "clipboard-provider [subcommand]",
+		Short: "Copy and paste for Neovim's clipboard provider, preserving the register type",
+	}
+	cmd.AddCommand(clipboardProviderCopyCmd(ctx, logger))
+	cmd.AddCommand(clipboardProviderPasteCmd(ctx, logger))
+	return cmd
+}
+
+func clipboardProviderCopyCmd(ctx context.Context, logger *log.Logger) *cobra.Command {
+	var registerType string
+
+	cmd := &cobra.Command{
+		Use:   "copy",
+		Short: "Copies the lines read from stdin to the host machine along with their register type",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			c := client.New()
+
+			lines, err := readBuffer(bufio.NewReader(os.Stdin))
+			if err != nil {
+				return invalidInput(fmt.Errorf("can not get input to copy: %w", err))
+			}
+
+			command := client.Command{
+				Name:      "copy",
+				Arguments: []string{providerContent(lines, registerType)},
+				Options:   map[string]string{"regtype": registerType},
+			}
+
+			_, err = c.Send(ctx, command)
+
+			// Older servers can't store the register type, but the content
+			// can still be copied.
+			var unsupported *client.UnsupportedError
+			if errors.As(err, &unsupported) {
+				command.Options = nil
+				_, err = c.Send(ctx, command)
+			}
+
+			if err != nil {
+				return fmt.Errorf("can not send command: %w", err)
+			}
+
+			return nil
+		},
+	}
+
+	addQuietFlag(cmd)
+	cmd.Flags().StringVar(&registerType, "regtype", "v", "the register type of the copied lines: v, V or b followed by the block width")
+
+	return cmd
+}
+
+func clipboardProviderPasteCmd(ctx context.Context, logger *log.Logger) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "paste",
+		Short: "Prints the host machine's clipboard as JSON lines and register type",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			c := client.New()
+
+			var result builtin.PasteResult
+			contents, err := c.Send(ctx, client.Command{
+				Name:    "paste",
+				Options: map[string]string{"format": "json"},
+			})
+
+			var unsupported *client.UnsupportedError
+			switch {
+			case errors.As(err, &unsupported):
+				// Older servers only return the content, so the register
+				// type is inferred.
+				contents, err = c.SendCommand(ctx, "paste")
+				result.Content = string(contents)
+			case err == nil:
+				err = json.Unmarshal(contents, &result)
+			}
+
+			if err != nil {
+				return fmt.Errorf("can not send command: %w", err)
+			}
+
+			return json.NewEncoder(os.Stdout).Encode(providerLines(result.Content, result.RegisterType))
+		},
+	}
+
+	addQuietFlag(cmd)
+
+	return cmd
+}
+
+// providerContent returns the clipboard content for lines of the given
+// register type. Linewise content ends with a newline, matching how Neovim
+// writes linewise registers to the system clipboard.
+func providerContent(lines string, registerType string) string {
+	if registerType == "V" {
+		return lines + "\n"
+	}
+
+	return lines
+}
+
+// providerLines splits clipboard content into the lines and register type
+// expected by Neovim. When the register type is unknown it is inferred from
+// the content, treating content ending in a newline as linewise.
+func providerLines(content string, registerType string) providerPaste {
+	if registerType == "" {
+		registerType = "v"
+		if strings.HasSuffix(content, "\n") {
+			registerType = "V"
+		}
+	}
+
+	if registerType == "V" {
+		content = strings.TrimSuffix(content, "\n")
+	}
+
+	return providerPaste{
+		Lines:        strings.Split(content, "\n"),
+		RegisterType: registerType,
+	}
+}
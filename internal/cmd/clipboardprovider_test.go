@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProviderLines(t *testing.T) {
+	testCases := map[string]struct {
+		content      string
+		registerType string
+		expected     providerPaste
+	}{
+		"charwise":          {content: "foo", registerType: "v", expected: providerPaste{Lines: []string{"foo"}, RegisterType: "v"}},
+		"linewise":          {content: "foo\nbar\n", registerType: "V", expected: providerPaste{Lines: []string{"foo", "bar"}, RegisterType: "V"}},
+		"blockwise":         {content: "ab\ncd", registerType: "b2", expected: providerPaste{Lines: []string{"ab", "cd"}, RegisterType: "b2"}},
+		"inferred charwise": {content: "foo\nbar", expected: providerPaste{Lines: []string{"foo", "bar"}, RegisterType: "v"}},
+		"inferred linewise": {content: "foo\n", expected: providerPaste{Lines: []string{"foo"}, RegisterType: "V"}},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.expected, providerLines(tc.content, tc.registerType))
+		})
+	}
+}
+
+func TestProviderContent_RoundTrip(t *testing.T) {
+	for _, registerType := range []string{"v", "V", "b3"} {
+		content := providerContent("foo\nbar", registerType)
+		result := providerLines(content, registerType)
+
+		require.Equal(t, []string{"foo", "bar"}, result.Lines)
+		require.Equal(t, registerType, result.RegisterType)
+	}
+}
@@ -3,20 +3,25 @@ package cmd
 import (
 	"bufio"
 	"context"
+	"fmt"
 	"io"
 	"log"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/blakewilliams/remote-development-manager/internal/client"
 	"github.com/spf13/cobra"
 )
 
 func newCopyCmd(ctx context.Context, logger *log.Logger) *cobra.Command {
-	return &cobra.Command{
+	var ttl time.Duration
+
+	cmd := &cobra.Command{
 		Use:   "copy",
 		Short: "Copies stdin to clipboard on the host machine.",
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
 			ctx, cancel := context.WithCancel(ctx)
 			defer cancel()
 
@@ -25,19 +30,28 @@ func newCopyCmd(ctx context.Context, logger *log.Logger) *cobra.Command {
 			content, err := readBuffer(bufio.NewReader(os.Stdin))
 
 			if err != nil {
-				log.Printf("Can not get input to copy: %v", err)
-				return
+				return invalidInput(fmt.Errorf("can not get input to copy: %w", err))
+			}
+
+			command := client.Command{Name: "copy", Arguments: []string{content}}
+			if ttl > 0 {
+				command.Options = map[string]string{"ttl": ttl.String()}
 			}
 
-			_, err = c.SendCommand(ctx, "copy", content)
+			_, err = c.Send(ctx, command)
 
 			if err != nil {
-				log.Printf("Can not send command: %v", err)
-				cancel()
-				return
+				return fmt.Errorf("can not send command: %w", err)
 			}
+
+			return nil
 		},
 	}
+
+	addQuietFlag(cmd)
+	cmd.Flags().DurationVar(&ttl, "ttl", 0, "clear the host clipboard after the given duration, e.g. 30s, if it still holds the copied value")
+
+	return cmd
 }
 
 func readBuffer(r *bufio.Reader) (string, error) {
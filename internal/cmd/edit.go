@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/blakewilliams/remote-development-manager/internal/client"
+	"github.com/spf13/cobra"
+)
+
+func newEditCmd(ctx context.Context, logger *log.Logger) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "edit file",
+		Short: "Edits a file in the host machine's editor, usable as $EDITOR",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			c := client.New()
+			// Editing takes as long as it takes.
+			c.SetTimeout(0)
+
+			path := args[0]
+			mode := os.FileMode(0644)
+
+			content, err := os.ReadFile(path)
+			if errors.Is(err, os.ErrNotExist) {
+				content = nil
+			} else if err != nil {
+				return fmt.Errorf("can not read file to edit: %w", err)
+			}
+
+			if info, err := os.Stat(path); err == nil {
+				mode = info.Mode().Perm()
+			}
+
+			// Files aren't necessarily valid UTF-8, which JSON can't carry.
+			result, err := c.SendCommand(ctx, "edit", path, base64.StdEncoding.EncodeToString(content))
+			if err != nil {
+				return fmt.Errorf("can not send command: %w", err)
+			}
+
+			if bytes.Equal(content, result) {
+				return nil
+			}
+
+			if err := os.WriteFile(path, result, mode); err != nil {
+				return fmt.Errorf("can not write edited file: %w", err)
+			}
+
+			return nil
+		},
+	}
+
+	addQuietFlag(cmd)
+
+	return cmd
+}
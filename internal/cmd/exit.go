@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+
+	"github.com/blakewilliams/remote-development-manager/internal/client"
+	"github.com/spf13/cobra"
+)
+
+// Exit codes of client commands, so editor and tmux integrations can tell
+// failures apart and fall back, e.g. to a local clipboard.
+const (
+	ExitOK = 0
+	// ExitFailure is used for errors that have no more specific code.
+	ExitFailure = 1
+	// ExitInvalid means the command was given invalid arguments, flags or
+	// input.
+	ExitInvalid = 2
+	// ExitUnreachable means the server could not be connected to.
+	ExitUnreachable = 3
+	// ExitDenied means the server socket could not be accessed, or the server
+	// refused the command, e.g. because it was denied on the host or rate
+	// limited.
+	ExitDenied = 4
+	// ExitBackend means the command failed or timed out on the host.
+	ExitBackend = 5
+)
+
+// exitError is an error with an explicit exit code.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// invalidInput returns an error exiting with ExitInvalid.
+func invalidInput(err error) error {
+	return &exitError{code: ExitInvalid, err: err}
+}
+
+// quiet suppresses the error messages of client commands, leaving only their
+// exit code.
+var quiet bool
+
+func addQuietFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "don't print errors, only report them through the exit code")
+}
+
+// exitCode returns the exit code for an error returned by a command.
+func exitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+
+	var serverErr *client.ServerError
+	if errors.As(err, &serverErr) {
+		switch serverErr.Code {
+		case "not_found", "invalid_argument":
+			return ExitInvalid
+		case "denied", "rate_limited":
+			return ExitDenied
+		default:
+			return ExitBackend
+		}
+	}
+
+	var unsupported *client.UnsupportedError
+	if errors.As(err, &unsupported) {
+		return ExitBackend
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		if errors.Is(err, os.ErrPermission) {
+			return ExitDenied
+		}
+		return ExitUnreachable
+	}
+
+	// The server accepted the command but didn't respond in time.
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ExitBackend
+	}
+
+	return ExitFailure
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/blakewilliams/remote-development-manager/internal/client"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExitCode(t *testing.T) {
+	testCases := map[string]struct {
+		err  error
+		code int
+	}{
+		"success":         {err: nil, code: ExitOK},
+		"unknown":         {err: errors.New("oops"), code: ExitFailure},
+		"invalid input":   {err: invalidInput(errors.New("no url")), code: ExitInvalid},
+		"invalid command": {err: &client.ServerError{Code: "invalid_argument"}, code: ExitInvalid},
+		"denied":          {err: &client.ServerError{Code: "denied"}, code: ExitDenied},
+		"rate limited":    {err: &client.ServerError{Code: "rate_limited"}, code: ExitDenied},
+		"backend failure": {err: fmt.Errorf("can not send command: %w", &client.ServerError{Code: "failed"}), code: ExitBackend},
+		"timeout":         {err: &client.ServerError{Code: "timeout"}, code: ExitBackend},
+		"client timeout":  {err: fmt.Errorf("can not send command: %w", context.DeadlineExceeded), code: ExitBackend},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.code, exitCode(tc.err))
+		})
+	}
+}
+
+func TestExitCode_Dial(t *testing.T) {
+	dir := t.TempDir()
+	_, err := unixClient(filepath.Join(dir, "missing.sock")).SendCommand(context.Background(), "paste")
+	require.Equal(t, ExitUnreachable, exitCode(err))
+
+	if os.Geteuid() == 0 {
+		t.Skip("socket permissions don't apply to root")
+	}
+
+	path := filepath.Join(dir, "rdm.sock")
+	listener, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	defer listener.Close()
+	require.NoError(t, os.Chmod(path, 0))
+
+	_, err = unixClient(path).SendCommand(context.Background(), "paste")
+	require.Equal(t, ExitDenied, exitCode(err))
+}
+
+func TestExitCode_Commands(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("RDM_ADDR", filepath.Join(dir, "missing.sock"))
+
+	testCases := map[string]struct {
+		cmd  *cobra.Command
+		args []string
+	}{
+		"edit":                     {cmd: newEditCmd(context.Background(), nil), args: []string{filepath.Join(dir, "file")}},
+		"clipboard-provider paste": {cmd: clipboardProviderPasteCmd(context.Background(), nil), args: []string{}},
+		"run":                      {cmd: newRunCmd(context.Background(), nil), args: []string{"deploy"}},
+		"sessions":                 {cmd: newSessionsCmd(context.Background(), nil), args: []string{}},
+		"watch":                    {cmd: newWatchCmd(context.Background(), nil), args: []string{}},
+		"version":                  {cmd: newVersionCmd(context.Background(), nil), args: []string{}},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			tc.cmd.SetArgs(tc.args)
+			tc.cmd.SetOut(io.Discard)
+			tc.cmd.SetErr(io.Discard)
+
+			require.Equal(t, ExitUnreachable, exitCode(tc.cmd.Execute()))
+		})
+	}
+}
+
+func TestExitCode_AuditTail(t *testing.T) {
+	cmd := newAuditTailCmd(context.Background(), nil)
+	cmd.SetArgs([]string{"--since", "yesterday"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+
+	require.Equal(t, ExitInvalid, exitCode(cmd.Execute()))
+}
+
+func unixClient(path string) *client.Client {
+	return client.NewWithTransport(client.Transport{Network: client.NetworkUnix, Address: path})
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/blakewilliams/remote-development-manager/internal/client"
+	"github.com/spf13/cobra"
+)
+
+// gitCredentialTimeout allows time for the user to respond to the
+// confirmation dialog on the host.
+const gitCredentialTimeout = 2 * time.Minute
+
+func newGitCredentialCmd(ctx context.Context, logger *log.Logger) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "git-credential get|store|erase",
+		Short: "A git credential helper backed by the host machine's credentials",
+		Long: `A git credential helper backed by the host machine's credentials.
+	Configure git on the remote machine with:
+
+	git config --global credential.helper "!rdm git-credential"`,
+		Args:      cobra.ExactValidArgs(1),
+		ValidArgs: []string{"get", "store", "erase"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			c := client.New()
+			c.SetTimeout(gitCredentialTimeout)
+
+			input, err := readBuffer(bufio.NewReader(os.Stdin))
+			if err != nil {
+				return invalidInput(fmt.Errorf("can not read credential: %w", err))
+			}
+
+			result, err := c.SendCommand(ctx, "git-credential", args[0], input)
+			if err != nil {
+				return fmt.Errorf("can not send command: %w", err)
+			}
+
+			fmt.Print(string(result))
+			return nil
+		},
+	}
+
+	addQuietFlag(cmd)
+
+	return cmd
+}
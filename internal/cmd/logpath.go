@@ -3,20 +3,25 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"os"
-	"path/filepath"
+	"log"
 
+	"github.com/blakewilliams/remote-development-manager/internal/client"
+	"github.com/blakewilliams/remote-development-manager/internal/config"
 	"github.com/spf13/cobra"
 )
 
-var LogPath string = filepath.Join(os.TempDir(), "rdm.log")
-
 func newLogpathCmd(ctx context.Context) *cobra.Command {
 	return &cobra.Command{
 		Use:   "logpath",
-		Short: "Prints the location of the log file",
+		Short: "Prints the location of the active log file",
 		Run: func(cmd *cobra.Command, args []string) {
-			fmt.Println(LogPath)
+			cfg, err := config.Load(config.Path())
+			if err != nil {
+				log.Printf("Can not load config: %v", err)
+				return
+			}
+
+			fmt.Println(cfg.LogPath(client.Instance))
 		},
 	}
 }
@@ -2,34 +2,94 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
-	"os"
+	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/blakewilliams/remote-development-manager/internal/client"
+	"github.com/blakewilliams/remote-development-manager/internal/handler/oauthrelay"
 	"github.com/spf13/cobra"
 )
 
 func newOpenCmd(ctx context.Context, logger *log.Logger) *cobra.Command {
-	return &cobra.Command{
+	var noRelay bool
+
+	cmd := &cobra.Command{
 		Use:   "open url",
 		Short: "Sends given url to the open command",
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) == 0 {
-				cmd.Help()
-				os.Exit(0)
+				return invalidInput(errors.New("open requires a url"))
 			}
+			cmd.SilenceUsage = true
 			ctx, cancel := context.WithCancel(ctx)
 			defer cancel()
 
 			c := client.New()
 
+			if port, ok := oauthrelay.CallbackPort(args[0]); ok && !noRelay {
+				err := relayOpen(ctx, c, args[0], port)
+
+				// Older servers can't relay callbacks, but can still open
+				// the URL.
+				var unsupported *client.UnsupportedError
+				if !errors.As(err, &unsupported) {
+					if err != nil {
+						return fmt.Errorf("can not relay login callback: %w", err)
+					}
+					return nil
+				}
+			}
+
 			_, err := c.SendCommand(ctx, "open", args[0])
 
 			if err != nil {
-				log.Printf("Can not send command: %v", err)
-				cancel()
-				return
+				return fmt.Errorf("can not send command: %w", err)
 			}
+
+			return nil
 		},
 	}
+
+	addQuietFlag(cmd)
+	cmd.Flags().BoolVar(&noRelay, "no-relay", false, "don't relay localhost OAuth callbacks from the host machine")
+
+	return cmd
+}
+
+// relayOpen opens a URL with a localhost OAuth redirect on the host, waits for
+// the browser to be redirected, replays the callback against the callback
+// server on this machine and sends its response back to the browser.
+func relayOpen(ctx context.Context, c *client.Client, target string, port int) error {
+	c.SetTimeout(oauthrelay.CallbackTimeout + 30*time.Second)
+
+	result, err := c.SendCommand(ctx, "relay-open", target, strconv.Itoa(port))
+	if err != nil {
+		return err
+	}
+
+	var callback oauthrelay.Request
+	if err := json.Unmarshal(result, &callback); err != nil {
+		return err
+	}
+
+	response, err := oauthrelay.Replay(ctx, port, &callback)
+	if err != nil {
+		response = &oauthrelay.Response{
+			StatusCode: http.StatusBadGateway,
+			Body:       []byte(err.Error()),
+		}
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.SendCommand(ctx, "relay-response", callback.ID, string(data))
+	return err
 }
@@ -10,10 +10,11 @@ import (
 )
 
 func newPasteCmd(ctx context.Context, logger *log.Logger) *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "paste",
 		Short: "Prints the contents of host host machines clipboard",
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
 			ctx, cancel := context.WithCancel(ctx)
 			defer cancel()
 			c := client.New()
@@ -21,12 +22,15 @@ func newPasteCmd(ctx context.Context, logger *log.Logger) *cobra.Command {
 			result, err := c.SendCommand(ctx, "paste")
 
 			if err != nil {
-				log.Printf("Can not send command: %v", err)
-				cancel()
-				return
+				return fmt.Errorf("can not send command: %w", err)
 			}
 
 			fmt.Print(string(result))
+			return nil
 		},
 	}
+
+	addQuietFlag(cmd)
+
+	return cmd
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"log"
 
+	"github.com/blakewilliams/remote-development-manager/internal/client"
 	"github.com/spf13/cobra"
 )
 
@@ -14,15 +15,44 @@ var rootCmd = &cobra.Command{
 	Complete documentation is available at https://github.com/BlakeWilliams/remote-development-manager`,
 }
 
-func Execute(ctx context.Context, logger *log.Logger) error {
+// Execute runs the command given on the command line, printing any error to
+// logger unless it was run with --quiet, and returns the exit code.
+func Execute(ctx context.Context, logger *log.Logger) int {
+	// Errors are printed below so --quiet can suppress them.
+	rootCmd.SilenceErrors = true
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return invalidInput(err)
+	})
+	rootCmd.PersistentFlags().StringVar(&client.Instance, "instance", client.Instance, "name of the server instance to use, defaults to $RDM_INSTANCE")
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		return client.ValidateInstance(client.Instance)
+	}
+
 	rootCmd.AddCommand(newServerCmd(ctx, logger))
 	rootCmd.AddCommand(newCopyCmd(ctx, logger))
 	rootCmd.AddCommand(newPasteCmd(ctx, logger))
 	rootCmd.AddCommand(newOpenCmd(ctx, logger))
 	rootCmd.AddCommand(newSocketCmd(ctx))
+	rootCmd.AddCommand(newWhichTransportCmd(ctx, logger))
+	rootCmd.AddCommand(newSSHArgsCmd(ctx, logger))
+	rootCmd.AddCommand(newSSHCmd(ctx, logger))
 	rootCmd.AddCommand(newStopCmd(ctx, logger))
 	rootCmd.AddCommand(newServiceCmd(ctx, logger))
 	rootCmd.AddCommand(newLogpathCmd(ctx))
+	rootCmd.AddCommand(newVersionCmd(ctx, logger))
+	rootCmd.AddCommand(newRunCmd(ctx, logger))
+	rootCmd.AddCommand(newSessionsCmd(ctx, logger))
+	rootCmd.AddCommand(newWatchCmd(ctx, logger))
+	rootCmd.AddCommand(newTmuxSyncCmd(ctx, logger))
+	rootCmd.AddCommand(newClipboardProviderCmd(ctx, logger))
+	rootCmd.AddCommand(newGitCredentialCmd(ctx, logger))
+	rootCmd.AddCommand(newEditCmd(ctx, logger))
+	rootCmd.AddCommand(newAuditCmd(ctx, logger))
+
+	err := rootCmd.Execute()
+	if err != nil && !quiet {
+		logger.Printf("Error: %v", err)
+	}
 
-	return rootCmd.Execute()
+	return exitCode(err)
 }
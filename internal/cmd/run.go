@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/blakewilliams/remote-development-manager/internal/client"
+	"github.com/spf13/cobra"
+)
+
+func newRunCmd(ctx context.Context, logger *log.Logger) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "run command [args...]",
+		Short: "Runs a custom command defined in the host's config",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			c := client.New()
+
+			result, err := c.SendCommand(ctx, args[0], args[1:]...)
+
+			if err != nil {
+				return fmt.Errorf("can not send command: %w", err)
+			}
+
+			fmt.Print(string(result))
+			return nil
+		},
+	}
+
+	addQuietFlag(cmd)
+
+	return cmd
+}
@@ -2,57 +2,177 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"path/filepath"
+	"time"
 
+	"github.com/blakewilliams/remote-development-manager/internal/audit"
 	"github.com/blakewilliams/remote-development-manager/internal/client"
+	"github.com/blakewilliams/remote-development-manager/internal/config"
+	"github.com/blakewilliams/remote-development-manager/internal/handler/custom"
+	"github.com/blakewilliams/remote-development-manager/internal/handler/edit"
+	"github.com/blakewilliams/remote-development-manager/internal/handler/gitcredential"
+	"github.com/blakewilliams/remote-development-manager/internal/handler/oauthrelay"
 	"github.com/blakewilliams/remote-development-manager/internal/hostservice"
+	"github.com/blakewilliams/remote-development-manager/internal/hostservice/clipboard"
+	"github.com/blakewilliams/remote-development-manager/internal/hostservice/confirm"
+	"github.com/blakewilliams/remote-development-manager/internal/hostservice/editor"
+	"github.com/blakewilliams/remote-development-manager/internal/logging"
+	"github.com/blakewilliams/remote-development-manager/internal/ratelimit"
+	"github.com/blakewilliams/remote-development-manager/internal/secrets"
 	"github.com/blakewilliams/remote-development-manager/internal/server"
 	"github.com/spf13/cobra"
 )
 
 func newServerCmd(ctx context.Context, logger *log.Logger) *cobra.Command {
-	return &cobra.Command{
+	var (
+		logLevel  string
+		logFormat string
+	)
+
+	cmd := &cobra.Command{
 		Use:   "server",
 		Short: "Starts a server on the local machine.",
 		Run: func(cmd *cobra.Command, args []string) {
 			ctx, cancel := context.WithCancel(ctx)
 			defer cancel()
 
-			logFile := updateLoggerForServer(logger)
-			defer logFile.Close()
+			level, err := logging.ParseLevel(logLevel)
+			if err != nil {
+				logger.Printf("Server could not be started: %v\n", err)
+				return
+			}
+
+			format, err := logging.ParseFormat(logFormat)
+			if err != nil {
+				logger.Printf("Server could not be started: %v\n", err)
+				return
+			}
 
-			s := server.New(client.UnixSocketPath(), hostservice.New(), logger)
-			err := s.Listen(ctx)
+			cfg, err := config.Load(config.Path())
+			if err != nil {
+				logger.Printf("Server could not be started: %v\n", err)
+				return
+			}
 
+			serverLogger, logFile, err := newServerLogger(cfg, level, format)
 			if err != nil {
 				logger.Printf("Server could not be started: %v\n", err)
+				return
+			}
+			defer logFile.Close()
+
+			host, err := newHostService(cfg)
+			if err != nil {
+				serverLogger.Error("server could not be started", "error", err)
+				return
+			}
+
+			s := server.New(client.UnixSocketPath(), host, serverLogger)
+			if err := s.Register(gitcredential.New(confirm.Confirm)); err != nil {
+				serverLogger.Error("server could not be started", "error", err)
+				return
+			}
+			if err := oauthrelay.Register(s, host); err != nil {
+				serverLogger.Error("server could not be started", "error", err)
+				return
+			}
+			if err := s.Register(edit.New(editor.New(cfg.Editor.Command, cfg.Editor.WaitForSave))); err != nil {
+				serverLogger.Error("server could not be started", "error", err)
+				return
+			}
+			if err := custom.Register(s, cfg.Commands); err != nil {
+				serverLogger.Error("server could not be started", "error", err)
+				return
+			}
+
+			s.SetRateLimiter(ratelimit.New(cfg.Limits()))
+			s.SetTimeouts(cfg.Timeouts())
+
+			// The config was validated when it was loaded.
+			secretMode, _ := secrets.ParseMode(cfg.Secrets.Mode)
+			secretRules, _ := cfg.SecretRules()
+			s.SetSecretGuard(
+				&secrets.Guard{Rules: secretRules, Mode: secretMode, Confirm: confirm.Confirm},
+				time.Duration(cfg.Secrets.ClearAfterSeconds)*time.Second,
+			)
+
+			if !cfg.Audit.Disabled {
+				auditPath, err := cfg.AuditPath()
+				if err != nil {
+					serverLogger.Error("server could not be started", "error", err)
+					return
+				}
+
+				auditLog, err := audit.Open(auditPath, cfg.Audit.IncludeContent)
+				if err != nil {
+					serverLogger.Error("server could not be started", "error", err)
+					return
+				}
+				defer auditLog.Close()
+
+				s.SetAuditLog(auditLog)
+			}
+
+			err = s.Listen(ctx)
+
+			if err != nil && !errors.Is(err, context.Canceled) {
+				serverLogger.Error("server could not be started", "error", err)
 				cancel()
 				return
 			}
 		},
 	}
+
+	cmd.Flags().StringVar(&logLevel, "log-level", "info", "minimum level of log entries: debug, info, warn or error")
+	cmd.Flags().StringVar(&logFormat, "log-format", "logfmt", "format of log entries: logfmt or json")
+
+	return cmd
+}
+
+// newHostService returns the host service using the configured clipboard.
+func newHostService(cfg *config.Config) (*hostservice.HostService, error) {
+	if cfg.Clipboard.Backend != config.ClipboardMemory {
+		return hostservice.New(), nil
+	}
+
+	if !cfg.Clipboard.Persist {
+		return hostservice.NewWithClipboard(clipboard.NewMemory()), nil
+	}
+
+	path, err := cfg.ClipboardPath()
+	if err != nil {
+		return nil, err
+	}
+
+	memory, err := clipboard.NewPersistentMemory(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return hostservice.NewWithClipboard(memory), nil
 }
 
-// We have an existing logger attached to stderr for human consumption. Rather
-// than creating a new one for the server we are about to launch, reconfigure
-// the existing one with more appropriate settings for a server.
-func updateLoggerForServer(logger *log.Logger) io.Closer {
-	logFile, err := os.OpenFile(LogPath, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0666)
+// newServerLogger returns a structured logger for the server. Since our server
+// process runs in the foreground as well as writes to a log, it logs to stdout
+// and the rotated log file at the same time.
+func newServerLogger(cfg *config.Config, level logging.Level, format logging.Format) (*logging.Logger, io.Closer, error) {
+	path := cfg.LogPath(client.Instance)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, nil, fmt.Errorf("could not create log directory: %w", err)
+	}
+
+	logFile, err := logging.OpenRotatingFile(path, cfg.LogRotation())
 	if err != nil {
-		panic(fmt.Errorf("unable to open logfile %s: %w", LogPath, err))
+		return nil, nil, err
 	}
 
-	// Since our server process runs in the foreground as well as writes to a
-	// log, we will log to stdout and the log file at the same time.
 	logSink := io.MultiWriter(os.Stdout, logFile)
-	logger.SetOutput(logSink)
-
-	// In a server context we want timestamps and file locations in our logs.
-	logger.SetFlags(log.LstdFlags | log.LUTC | log.Lshortfile | log.Lmicroseconds)
 
 	// Return this file so it can be closed when the server exits.
-	return logFile
+	return logging.New(logSink, level, format), logFile, nil
 }
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/blakewilliams/remote-development-manager/internal/client"
+	"github.com/blakewilliams/remote-development-manager/internal/session"
+	"github.com/spf13/cobra"
+)
+
+func newSessionsCmd(ctx context.Context, logger *log.Logger) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sessions",
+		Short: "Lists the remote sessions that have recently sent commands to the server",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			c := client.New()
+
+			result, err := c.SendCommand(ctx, "sessions")
+
+			if err != nil {
+				return fmt.Errorf("can not send command: %w", err)
+			}
+
+			var sessions []session.Session
+			if err := json.Unmarshal(result, &sessions); err != nil {
+				return fmt.Errorf("can not parse sessions: %w", err)
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "SESSION\tNAME\tUSER@HOST\tLAST COMMAND\tLAST SEEN\tREQUESTS")
+			for _, s := range sessions {
+				fmt.Fprintf(w, "%s\t%s\t%s@%s\t%s\t%s ago\t%d\n",
+					s.SessionID,
+					s.String(),
+					s.User,
+					s.Hostname,
+					s.LastCommand,
+					time.Since(s.LastSeen).Round(time.Second),
+					s.Requests,
+				)
+			}
+			return w.Flush()
+		},
+	}
+
+	addQuietFlag(cmd)
+
+	return cmd
+}
@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/blakewilliams/remote-development-manager/internal/client"
+	"github.com/spf13/cobra"
+)
+
+// serverStartTimeout is how long `rdm ssh` waits for a server it started to
+// accept commands.
+const serverStartTimeout = 5 * time.Second
+
+func newSSHCmd(ctx context.Context, logger *log.Logger) *cobra.Command {
+	var tcp bool
+	var remoteSocket string
+
+	cmd := &cobra.Command{
+		Use:   "ssh [flags] [ssh options] destination [command]",
+		Short: "Runs ssh, forwarding the server to the remote machine and starting it if needed",
+		// Flags are parsed by splitSSHArgs, passing ssh's options through.
+		DisableFlagParsing: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			invocation := splitSSHArgs(cmd, args)
+			// ParseFlags is a no-op without flag parsing, so the flags,
+			// including inherited ones like --instance, are parsed here.
+			cmd.InheritedFlags()
+			if err := cmd.Flags().Parse(invocation.own); err != nil {
+				return invalidInput(err)
+			}
+			if help, _ := cmd.Flags().GetBool("help"); help {
+				return cmd.Help()
+			}
+			if err := client.ValidateInstance(client.Instance); err != nil {
+				return invalidInput(err)
+			}
+			if invocation.destination == "" {
+				return invalidInput(errors.New("ssh requires a destination, e.g. user@host"))
+			}
+			cmd.SilenceUsage = true
+
+			sshPath, err := exec.LookPath("ssh")
+			if err != nil {
+				return fmt.Errorf("can not find ssh: %w", err)
+			}
+
+			socketPath := client.UnixSocketPath()
+			if err := ensureServer(ctx, socketPath); err != nil {
+				return &exitError{code: ExitUnreachable, err: err}
+			}
+
+			remote := client.Transport{Network: client.NetworkUnix, Address: remoteSocket}
+			if tcp {
+				remote = client.Transport{Network: client.NetworkTCP, Address: fmt.Sprintf("127.0.0.1:%d", defaultRemotePort)}
+			} else if remoteSocket == "" {
+				remote.Address = client.RemoteSocketPath(remoteUser(ctx, sshPath, invocation.options, invocation.destination))
+			}
+
+			argv := append([]string{"ssh"}, sshArgs(socketPath, remote, invocation)...)
+			return syscall.Exec(sshPath, argv, os.Environ())
+		},
+	}
+
+	cmd.Flags().BoolVar(&tcp, "tcp", false, fmt.Sprintf("forward the server to port %d instead of a socket, which any user on the remote machine can connect to", defaultRemotePort))
+	cmd.Flags().StringVar(&remoteSocket, "remote-socket", "", "path of the socket on the remote machine, defaults to a path the client looks for")
+	// -q is left to ssh.
+	cmd.Flags().BoolVar(&quiet, "quiet", false, "don't print errors, only report them through the exit code")
+
+	return cmd
+}
+
+// sshOptionsWithValue are the ssh options taking a value, which is needed to
+// tell option values and the destination apart.
+const sshOptionsWithValue = "BbcDEeFIiJLlmOoPpQRSWw"
+
+// sshInvocation is the command line of `rdm ssh` split by splitSSHArgs.
+type sshInvocation struct {
+	// own are the flags of rdm ssh itself.
+	own []string
+	// options are the ssh options before the destination.
+	options     []string
+	destination string
+	// rest are the arguments after the destination, e.g. a command.
+	rest []string
+}
+
+// splitSSHArgs separates the flags of rdm ssh from the arguments for ssh.
+// Since ssh has no long options, every --flag before the destination belongs
+// to rdm.
+func splitSSHArgs(cmd *cobra.Command, args []string) sshInvocation {
+	var invocation sshInvocation
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		switch {
+		case strings.HasPrefix(arg, "--"):
+			invocation.own = append(invocation.own, arg)
+
+			name := strings.TrimPrefix(arg, "--")
+			flag := cmd.Flag(name)
+			if flag != nil && flag.Value.Type() != "bool" && !strings.Contains(name, "=") && i+1 < len(args) {
+				i++
+				invocation.own = append(invocation.own, args[i])
+			}
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			invocation.options = append(invocation.options, arg)
+
+			// Options are grouped, e.g. -tti key, and the value of the last
+			// one is either attached or the next argument.
+			for j := 1; j < len(arg); j++ {
+				if strings.IndexByte(sshOptionsWithValue, arg[j]) < 0 {
+					continue
+				}
+
+				if j == len(arg)-1 && i+1 < len(args) {
+					i++
+					invocation.options = append(invocation.options, args[i])
+				}
+				break
+			}
+		default:
+			invocation.destination = arg
+			invocation.rest = args[i+1:]
+			return invocation
+		}
+	}
+
+	return invocation
+}
+
+// sshArgs returns the arguments for ssh to run invocation, forwarding the
+// server listening on localSocket to remote. RDM_ADDR is sent so clients find
+// non-default sockets, which requires the remote sshd to accept it with
+// AcceptEnv.
+func sshArgs(localSocket string, remote client.Transport, invocation sshInvocation) []string {
+	args := sshForwardArgs(localSocket, remote)
+	args = append(args, "-o", fmt.Sprintf("SetEnv=RDM_ADDR=%s://%s", remote.Network, remote.Address))
+	args = append(args, invocation.options...)
+	args = append(args, invocation.destination)
+
+	return append(args, invocation.rest...)
+}
+
+// remoteUser returns the user ssh logs in as for destination, as resolved by
+// `ssh -G` from the destination, options like -l and ssh_config. When ssh
+// can't resolve it, the user is taken from the options and destination.
+func remoteUser(ctx context.Context, sshPath string, options []string, destination string) string {
+	args := append(append([]string{"-G"}, options...), destination)
+	if output, err := exec.CommandContext(ctx, sshPath, args...).Output(); err == nil {
+		if user := sshConfigUser(output); user != "" {
+			return user
+		}
+	}
+
+	return destinationUser(options, destination)
+}
+
+// sshConfigUser returns the user in the output of `ssh -G`.
+func sshConfigUser(output []byte) string {
+	for _, line := range strings.Split(string(output), "\n") {
+		if user := strings.TrimPrefix(line, "user "); user != line {
+			return strings.TrimSpace(user)
+		}
+	}
+
+	return ""
+}
+
+// destinationUser returns the user given by -l or in destination, falling back
+// to the current user.
+func destinationUser(options []string, destination string) string {
+	for i, option := range options {
+		if option == "-l" && i+1 < len(options) {
+			return options[i+1]
+		}
+		if strings.HasPrefix(option, "-l") && len(option) > 2 {
+			return option[2:]
+		}
+	}
+
+	destination = strings.TrimPrefix(destination, "ssh://")
+	if i := strings.LastIndex(destination, "@"); i > 0 {
+		return destination[:i]
+	}
+
+	return client.CurrentIdentity().User
+}
+
+// ensureServer starts a server in the background unless one is already
+// accepting commands on socketPath.
+func ensureServer(ctx context.Context, socketPath string) error {
+	c := client.NewWithSocketPath(socketPath)
+	if _, err := c.SendCommand(ctx, "status"); err == nil {
+		return nil
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("can not start server: %w", err)
+	}
+
+	args := []string{"server"}
+	if client.Instance != "" {
+		args = append(args, "--instance", client.Instance)
+	}
+
+	server := exec.Command(executable, args...)
+	// The server outlives this process, which is replaced by ssh, and must
+	// not receive signals sent to the terminal.
+	server.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := server.Start(); err != nil {
+		return fmt.Errorf("can not start server: %w", err)
+	}
+	server.Process.Release()
+
+	deadline := time.Now().Add(serverStartTimeout)
+	for {
+		_, err := c.SendCommand(ctx, "status")
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("started a server, but it isn't accepting commands: %w", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/blakewilliams/remote-development-manager/internal/client"
+	"github.com/spf13/cobra"
+)
+
+// defaultRemotePort is the port the server is forwarded to when forwarding
+// over TCP, matching client.DefaultTCPAddr.
+const defaultRemotePort = 7391
+
+func newSSHArgsCmd(ctx context.Context, logger *log.Logger) *cobra.Command {
+	var tcp bool
+	var remoteSocket string
+	var remoteUser string
+
+	cmd := &cobra.Command{
+		Use:   "ssh-args",
+		Short: "Prints ssh arguments forwarding the server to a remote machine, e.g. ssh $(rdm ssh-args) user@host",
+		Run: func(cmd *cobra.Command, args []string) {
+			remote := client.Transport{Network: client.NetworkUnix, Address: remoteSocket}
+			if tcp {
+				remote = client.Transport{Network: client.NetworkTCP, Address: fmt.Sprintf("127.0.0.1:%d", defaultRemotePort)}
+			} else if remoteSocket == "" {
+				remote.Address = client.RemoteSocketPath(remoteUser)
+			}
+
+			fmt.Println(strings.Join(sshForwardArgs(client.UnixSocketPath(), remote), " "))
+		},
+	}
+
+	cmd.Flags().BoolVar(&tcp, "tcp", false, fmt.Sprintf("forward the server to port %d instead of a socket, which any user on the remote machine can connect to", defaultRemotePort))
+	cmd.Flags().StringVar(&remoteSocket, "remote-socket", "", "path of the socket on the remote machine, defaults to a path the client looks for")
+	cmd.Flags().StringVar(&remoteUser, "remote-user", client.CurrentIdentity().User, "user on the remote machine, used to name the default remote socket")
+
+	return cmd
+}
+
+// sshForwardArgs returns the ssh arguments forwarding the server listening on
+// localSocket to remote. Stale sockets left behind by earlier connections are
+// replaced, since ssh otherwise fails to forward to them, and ssh exits rather
+// than connecting without the forward, e.g. when another user took the path.
+func sshForwardArgs(localSocket string, remote client.Transport) []string {
+	args := []string{"-R", remote.Address + ":" + localSocket, "-o", "ExitOnForwardFailure=yes"}
+	if remote.Network == client.NetworkUnix {
+		args = append(args, "-o", "StreamLocalBindUnlink=yes")
+	}
+
+	return args
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/blakewilliams/remote-development-manager/internal/client"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSSHForwardArgs(t *testing.T) {
+	args := sshForwardArgs("/tmp/rdm-501/rdm.sock", client.Transport{Network: client.NetworkUnix, Address: "/tmp/rdm-forwarded-blake.sock"})
+	require.Equal(t, []string{"-R", "/tmp/rdm-forwarded-blake.sock:/tmp/rdm-501/rdm.sock", "-o", "ExitOnForwardFailure=yes", "-o", "StreamLocalBindUnlink=yes"}, args)
+
+	args = sshForwardArgs("/tmp/rdm-501/rdm.sock", client.Transport{Network: client.NetworkTCP, Address: "127.0.0.1:7391"})
+	require.Equal(t, []string{"-R", "127.0.0.1:7391:/tmp/rdm-501/rdm.sock", "-o", "ExitOnForwardFailure=yes"}, args)
+}
+
+func TestSSHArgs(t *testing.T) {
+	invocation := sshInvocation{options: []string{"-i", "key"}, destination: "blake@devbox", rest: []string{"uptime"}}
+	args := sshArgs("/tmp/rdm-501/rdm.sock", client.Transport{Network: client.NetworkUnix, Address: "/tmp/rdm-forwarded-blake.sock"}, invocation)
+	require.Equal(t, []string{
+		"-R", "/tmp/rdm-forwarded-blake.sock:/tmp/rdm-501/rdm.sock",
+		"-o", "ExitOnForwardFailure=yes",
+		"-o", "StreamLocalBindUnlink=yes",
+		"-o", "SetEnv=RDM_ADDR=unix:///tmp/rdm-forwarded-blake.sock",
+		"-i", "key", "blake@devbox", "uptime",
+	}, args)
+
+	args = sshArgs("/tmp/rdm-501/rdm.sock", client.Transport{Network: client.NetworkTCP, Address: "127.0.0.1:7391"}, sshInvocation{destination: "devbox"})
+	require.Equal(t, []string{
+		"-R", "127.0.0.1:7391:/tmp/rdm-501/rdm.sock",
+		"-o", "ExitOnForwardFailure=yes",
+		"-o", "SetEnv=RDM_ADDR=tcp://127.0.0.1:7391",
+		"devbox",
+	}, args)
+}
+
+func TestSplitSSHArgs(t *testing.T) {
+	cmd := newSSHCmd(context.Background(), nil)
+
+	invocation := splitSSHArgs(cmd, []string{"--tcp", "-i", "key", "--remote-socket", "/tmp/rdm.sock", "-qAp2222", "-o", "User=blake", "devbox", "-t", "tmux"})
+	require.Equal(t, []string{"--tcp", "--remote-socket", "/tmp/rdm.sock"}, invocation.own)
+	require.Equal(t, []string{"-i", "key", "-qAp2222", "-o", "User=blake"}, invocation.options)
+	require.Equal(t, "devbox", invocation.destination)
+	require.Equal(t, []string{"-t", "tmux"}, invocation.rest)
+
+	invocation = splitSSHArgs(cmd, []string{"--remote-socket=/tmp/rdm.sock", "-qv"})
+	require.Equal(t, []string{"--remote-socket=/tmp/rdm.sock"}, invocation.own)
+	require.Equal(t, []string{"-qv"}, invocation.options)
+	require.Empty(t, invocation.destination)
+}
+
+func TestRemoteUser(t *testing.T) {
+	// ssh -G resolves the user from ssh_config.
+	ssh := filepath.Join(t.TempDir(), "ssh")
+	require.NoError(t, os.WriteFile(ssh, []byte("#!/bin/sh\necho hostname devbox\necho user configured\n"), 0700))
+	require.Equal(t, "configured", remoteUser(context.Background(), ssh, nil, "devbox"))
+
+	// Without ssh -G the options and destination are used.
+	missing := filepath.Join(t.TempDir(), "ssh")
+	require.Equal(t, "blake", remoteUser(context.Background(), missing, nil, "blake@devbox"))
+	require.Equal(t, "blake", remoteUser(context.Background(), missing, nil, "ssh://blake@devbox:2222"))
+	require.Equal(t, "admin", remoteUser(context.Background(), missing, []string{"-l", "admin"}, "devbox"))
+	require.Equal(t, "admin", remoteUser(context.Background(), missing, []string{"-ladmin"}, "devbox"))
+	require.Equal(t, client.CurrentIdentity().User, remoteUser(context.Background(), missing, nil, "devbox"))
+}
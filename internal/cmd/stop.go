@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"context"
+	"fmt"
 	"log"
 
 	"github.com/blakewilliams/remote-development-manager/internal/client"
@@ -9,20 +10,25 @@ import (
 )
 
 func newStopCmd(ctx context.Context, logger *log.Logger) *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "stop",
 		Short: "Stops the server",
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
 			ctx, cancel := context.WithCancel(ctx)
 			defer cancel()
 			c := client.New()
 			_, err := c.SendCommand(ctx, "stop")
 
 			if err != nil {
-				log.Printf("Can not send command: %v", err)
-				cancel()
-				return
+				return fmt.Errorf("can not send command: %w", err)
 			}
+
+			return nil
 		},
 	}
+
+	addQuietFlag(cmd)
+
+	return cmd
 }
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/blakewilliams/remote-development-manager/internal/client"
+	"github.com/blakewilliams/remote-development-manager/internal/events"
+	"github.com/blakewilliams/remote-development-manager/internal/tmuxsync"
+	"github.com/spf13/cobra"
+)
+
+func newTmuxSyncCmd(ctx context.Context, logger *log.Logger) *cobra.Command {
+	var interval time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "tmux-sync",
+		Short: "Keeps tmux buffers and the host machine's clipboard in sync",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			c := client.New()
+
+			syncer := tmuxsync.New(tmuxsync.Tmux{}, func(ctx context.Context, content string) error {
+				_, err := c.SendCommand(ctx, "copy", content)
+				return err
+			})
+
+			go syncer.Poll(ctx, interval, func(err error) {
+				log.Printf("Can not sync tmux buffer: %v", err)
+			})
+
+			// Keep watching for host clipboard changes, reconnecting if the
+			// server restarts or the connection drops.
+			for {
+				err := c.Watch(ctx, []string{events.TypeClipboard}, func(event events.Event) error {
+					var data events.ClipboardData
+					if err := json.Unmarshal(event.Data, &data); err != nil {
+						return err
+					}
+
+					if err := syncer.FromHost(ctx, data.Content); err != nil {
+						log.Printf("Can not sync host clipboard: %v", err)
+					}
+					return nil
+				})
+
+				var unsupported *client.UnsupportedError
+				if errors.As(err, &unsupported) {
+					return fmt.Errorf("can not watch host clipboard: %w", err)
+				}
+				if err != nil && ctx.Err() == nil {
+					log.Printf("Can not watch host clipboard, retrying: %v", err)
+				}
+
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-time.After(5 * time.Second):
+				}
+			}
+		},
+	}
+
+	addQuietFlag(cmd)
+	cmd.Flags().DurationVar(&interval, "interval", 500*time.Millisecond, "how often to check tmux for new buffers")
+
+	return cmd
+}
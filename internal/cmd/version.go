@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/blakewilliams/remote-development-manager/internal/client"
+	"github.com/spf13/cobra"
+)
+
+func newVersionCmd(ctx context.Context, logger *log.Logger) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "version",
+		Short: "Prints the protocol version of this client and the server",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			fmt.Printf("client protocol: v%d\n", client.ProtocolVersion)
+
+			c := client.New()
+			capabilities, err := c.Capabilities(ctx)
+			if err != nil {
+				return fmt.Errorf("can not fetch server capabilities: %w", err)
+			}
+
+			fmt.Printf("server protocol: v%d\n", capabilities.Version)
+			fmt.Printf("server commands: %s\n", strings.Join(capabilities.CommandNames(), ", "))
+
+			switch {
+			case capabilities.Version < client.ProtocolVersion:
+				fmt.Println("The server is older than this client, upgrade rdm on the host machine to use every command.")
+			case capabilities.Version > client.ProtocolVersion:
+				fmt.Println("The server is newer than this client, upgrade rdm on this machine to use every command.")
+			}
+
+			return nil
+		},
+	}
+
+	addQuietFlag(cmd)
+
+	return cmd
+}
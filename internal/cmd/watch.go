@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/blakewilliams/remote-development-manager/internal/client"
+	"github.com/blakewilliams/remote-development-manager/internal/events"
+	"github.com/spf13/cobra"
+)
+
+func newWatchCmd(ctx context.Context, logger *log.Logger) *cobra.Command {
+	var types []string
+
+	cmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Prints events pushed by the host machine as JSON lines",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			c := client.New()
+
+			encoder := json.NewEncoder(os.Stdout)
+			err := c.Watch(ctx, types, func(event events.Event) error {
+				return encoder.Encode(event)
+			})
+
+			if err != nil && !errors.Is(err, context.Canceled) {
+				return fmt.Errorf("can not watch events: %w", err)
+			}
+
+			return nil
+		},
+	}
+
+	addQuietFlag(cmd)
+	cmd.Flags().StringSliceVar(&types, "type", nil, "only print events of the given type, e.g. clipboard")
+
+	return cmd
+}
@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/blakewilliams/remote-development-manager/internal/client"
+	"github.com/spf13/cobra"
+)
+
+func newWhichTransportCmd(ctx context.Context, logger *log.Logger) *cobra.Command {
+	return &cobra.Command{
+		Use:   "which-transport",
+		Short: "Prints how client commands will connect to the server and why",
+		Run: func(cmd *cobra.Command, args []string) {
+			discovery := client.DiscoverTransport(client.UnixSocketPath())
+
+			fmt.Println(discovery.Transport)
+			fmt.Printf("  chosen: %s\n", discovery.Transport.Reason)
+			for _, reason := range discovery.Skipped {
+				fmt.Printf("  skipped: %s\n", reason)
+			}
+		},
+	}
+}
@@ -0,0 +1,336 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/blakewilliams/remote-development-manager/internal/logging"
+	"github.com/blakewilliams/remote-development-manager/internal/ratelimit"
+	"github.com/blakewilliams/remote-development-manager/internal/secrets"
+)
+
+// Config holds the user configuration for the server.
+type Config struct {
+	// Commands are additional commands the server exposes to clients, keyed
+	// by command name.
+	Commands map[string]Command `json:"commands"`
+	// Editor is used by `rdm edit` to open files on the host.
+	Editor Editor `json:"editor"`
+	// Audit configures the log of actions taken on behalf of clients.
+	Audit Audit `json:"audit"`
+	// Log configures the server log.
+	Log Log `json:"log"`
+	// RateLimits limits how often each session may run a command, keyed by
+	// command name.
+	RateLimits map[string]RateLimit `json:"rate_limits"`
+	// TimeoutSeconds limits how long each command may run on the host, keyed
+	// by command name. Zero removes the limit, including any default one.
+	TimeoutSeconds map[string]float64 `json:"timeouts"`
+	// Secrets configures how clipboard contents that look like secrets are
+	// handled.
+	Secrets Secrets `json:"secrets"`
+	// Clipboard selects the clipboard the server copies to and pastes from.
+	Clipboard Clipboard `json:"clipboard"`
+}
+
+// Clipboard backends.
+const (
+	ClipboardSystem = "system"
+	ClipboardMemory = "memory"
+)
+
+// Clipboard configures the clipboard backend.
+type Clipboard struct {
+	// Backend is "system" (the default) to use the host's clipboard, or
+	// "memory" to keep the clipboard in the server, e.g. on hosts without a
+	// display.
+	Backend string `json:"backend"`
+	// Persist saves the memory clipboard to disk so it survives restarts.
+	Persist bool `json:"persist"`
+	// Path is where the memory clipboard is persisted. Defaults to clipboard
+	// in the state directory.
+	Path string `json:"path"`
+}
+
+// ClipboardPath returns the configured path of the persisted memory
+// clipboard, or the default location in the state directory.
+func (c *Config) ClipboardPath() (string, error) {
+	if c.Clipboard.Path != "" {
+		return c.Clipboard.Path, nil
+	}
+
+	dir, err := StateDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "clipboard"), nil
+}
+
+// Secrets configures scanning of copied and pasted clipboard contents for
+// secrets.
+type Secrets struct {
+	// Mode is what happens when contents look like a secret: "warn" (the
+	// default), "confirm", "block" or "off".
+	Mode string `json:"mode"`
+	// Patterns are additional regular expressions detecting secrets, keyed by
+	// the kind of secret.
+	Patterns map[string]string `json:"patterns"`
+	// ClearAfterSeconds clears secrets copied to the host clipboard after the
+	// given number of seconds, unless it has changed since. Zero disables it.
+	ClearAfterSeconds int `json:"clear_after_seconds"`
+}
+
+// SecretRules returns the default rules for detecting secrets along with the
+// configured patterns.
+func (c *Config) SecretRules() ([]secrets.Rule, error) {
+	rules := append([]secrets.Rule{}, secrets.DefaultRules...)
+
+	names := make([]string, 0, len(c.Secrets.Patterns))
+	for name := range c.Secrets.Patterns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		pattern, err := regexp.Compile(c.Secrets.Patterns[name])
+		if err != nil {
+			return nil, fmt.Errorf("invalid secret pattern %s: %w", name, err)
+		}
+		rules = append(rules, secrets.Rule{Name: name, Pattern: pattern})
+	}
+
+	return rules, nil
+}
+
+// RateLimit allows a session to run a command Burst times at once, with
+// another run becoming available every CooldownSeconds.
+type RateLimit struct {
+	Burst           int     `json:"burst"`
+	CooldownSeconds float64 `json:"cooldown_seconds"`
+	// Disabled removes the limit, including any default one.
+	Disabled bool `json:"disabled"`
+}
+
+// defaultRateLimit applies to commands that open windows on the host, as a
+// script stuck in a loop could otherwise open hundreds of them.
+var defaultRateLimit = ratelimit.Limit{Burst: 5, Cooldown: 2 * time.Second}
+
+// Limits returns the rate limits of each command. Opening URLs and custom
+// commands are limited by default, and zero values of configured limits use
+// the default.
+func (c *Config) Limits() map[string]ratelimit.Limit {
+	limits := map[string]ratelimit.Limit{
+		"open":       defaultRateLimit,
+		"relay-open": defaultRateLimit,
+	}
+	for name := range c.Commands {
+		limits[name] = defaultRateLimit
+	}
+
+	for name, configured := range c.RateLimits {
+		if configured.Disabled {
+			delete(limits, name)
+			continue
+		}
+
+		limit := defaultRateLimit
+		if configured.Burst > 0 {
+			limit.Burst = configured.Burst
+		}
+		if configured.CooldownSeconds > 0 {
+			limit.Cooldown = time.Duration(configured.CooldownSeconds * float64(time.Second))
+		}
+		limits[name] = limit
+	}
+
+	return limits
+}
+
+// defaultTimeout applies to commands that run clipboard and open commands on
+// the host, which can hang, e.g. xclip without an X server. It is shorter than
+// the client's timeout so clients receive the server's timeout error.
+const defaultTimeout = 5 * time.Second
+
+// Timeouts returns how long each command may run on the host. Copying,
+// pasting and opening URLs are limited by default.
+func (c *Config) Timeouts() map[string]time.Duration {
+	timeouts := map[string]time.Duration{
+		"copy":  defaultTimeout,
+		"paste": defaultTimeout,
+		"open":  defaultTimeout,
+	}
+
+	for name, seconds := range c.TimeoutSeconds {
+		if seconds <= 0 {
+			delete(timeouts, name)
+			continue
+		}
+
+		timeouts[name] = time.Duration(seconds * float64(time.Second))
+	}
+
+	return timeouts
+}
+
+// Log configures the location and rotation of the server log. Zero values use
+// the defaults.
+type Log struct {
+	// Path is the location of the server log. Defaults to rdm.log in the state
+	// directory.
+	Path string `json:"path"`
+	// MaxSizeMB is the size in megabytes the log grows to before it's rotated.
+	// Defaults to 10.
+	MaxSizeMB int `json:"max_size_mb"`
+	// MaxAgeDays is how many days the log is written to before it's rotated.
+	// Defaults to 7.
+	MaxAgeDays int `json:"max_age_days"`
+	// MaxFiles is the number of rotated logs that are kept. Defaults to 5.
+	MaxFiles int `json:"max_files"`
+}
+
+// LogPath returns the configured server log path, or the default location in
+// the state directory. Named server instances log to separate files. The
+// directory isn't created, so the server creates it before logging.
+func (c *Config) LogPath(instance string) string {
+	if c.Log.Path != "" {
+		return c.Log.Path
+	}
+
+	if instance != "" {
+		return filepath.Join(stateDir(), fmt.Sprintf("rdm-%s.log", instance))
+	}
+
+	return filepath.Join(stateDir(), "rdm.log")
+}
+
+// LogRotation returns the configured rotation limits of the server log with
+// defaults applied.
+func (c *Config) LogRotation() logging.Rotation {
+	rotation := logging.Rotation{
+		MaxSize:  10 << 20,
+		MaxAge:   7 * 24 * time.Hour,
+		MaxFiles: 5,
+	}
+
+	if c.Log.MaxSizeMB > 0 {
+		rotation.MaxSize = int64(c.Log.MaxSizeMB) << 20
+	}
+	if c.Log.MaxAgeDays > 0 {
+		rotation.MaxAge = time.Duration(c.Log.MaxAgeDays) * 24 * time.Hour
+	}
+	if c.Log.MaxFiles > 0 {
+		rotation.MaxFiles = c.Log.MaxFiles
+	}
+
+	return rotation
+}
+
+// Audit configures the audit log.
+type Audit struct {
+	// Path is the location of the audit log. Defaults to audit.log in the
+	// state directory.
+	Path string `json:"path"`
+	// Disabled turns off the audit log.
+	Disabled bool `json:"disabled"`
+	// IncludeContent records the content of copied and pasted values. This is
+	// off by default since the clipboard often contains secrets.
+	IncludeContent bool `json:"include_content"`
+}
+
+// AuditPath returns the configured audit log path, or the default location in
+// the state directory.
+func (c *Config) AuditPath() (string, error) {
+	if c.Audit.Path != "" {
+		return c.Audit.Path, nil
+	}
+
+	dir, err := StateDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "audit.log"), nil
+}
+
+// Editor configures the GUI editor used to edit remote files on the host.
+type Editor struct {
+	// Command is the editor and leading arguments, e.g. ["code", "--wait"].
+	// The file path is appended.
+	Command []string `json:"command"`
+	// WaitForSave returns the file once it's saved instead of waiting for the
+	// editor to exit.
+	WaitForSave bool `json:"wait_for_save"`
+}
+
+// Command is a user defined command that runs a program on the host.
+type Command struct {
+	// Exec is the program and leading arguments to run. Arguments sent by the
+	// client are appended.
+	Exec []string `json:"exec"`
+	// Arguments are the names of the arguments the client must send.
+	Arguments []string `json:"arguments"`
+	// Variadic allows the client to send additional arguments.
+	Variadic bool `json:"variadic"`
+}
+
+// Path returns the location of the configuration file. RDM_CONFIG takes
+// precedence, followed by $XDG_CONFIG_HOME/rdm/config.json and
+// ~/.config/rdm/config.json.
+func Path() string {
+	if path := os.Getenv("RDM_CONFIG"); path != "" {
+		return path
+	}
+
+	configDir := os.Getenv("XDG_CONFIG_HOME")
+	if configDir == "" {
+		configDir = filepath.Join(homeDir(), ".config")
+	}
+
+	return filepath.Join(configDir, "rdm", "config.json")
+}
+
+// Load reads the configuration file at path. A missing file results in an
+// empty configuration.
+func Load(path string) (*Config, error) {
+	cfg := &Config{}
+
+	contents, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read config %s: %w", path, err)
+	}
+
+	if err := json.Unmarshal(contents, cfg); err != nil {
+		return nil, fmt.Errorf("could not parse config %s: %w", path, err)
+	}
+
+	for name, command := range cfg.Commands {
+		if len(command.Exec) == 0 {
+			return nil, fmt.Errorf("command %s in config %s has no exec", name, path)
+		}
+	}
+
+	switch cfg.Clipboard.Backend {
+	case "", ClipboardSystem, ClipboardMemory:
+	default:
+		return nil, fmt.Errorf("invalid config %s: unknown clipboard backend %q, expected system or memory", path, cfg.Clipboard.Backend)
+	}
+
+	if _, err := secrets.ParseMode(cfg.Secrets.Mode); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	if _, err := cfg.SecretRules(); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/blakewilliams/remote-development-manager/internal/logging"
+	"github.com/blakewilliams/remote-development-manager/internal/ratelimit"
+	"github.com/blakewilliams/remote-development-manager/internal/secrets"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	cfg, err := Load(filepath.Join(dir, "missing.json"))
+	require.NoError(t, err)
+	require.Empty(t, cfg.Commands)
+
+	path := filepath.Join(dir, "config.json")
+	err = os.WriteFile(path, []byte(`{"commands": {"notify": {"exec": ["terminal-notifier", "-message"], "arguments": ["message"]}}}`), 0600)
+	require.NoError(t, err)
+
+	cfg, err = Load(path)
+	require.NoError(t, err)
+	require.Equal(t, []string{"terminal-notifier", "-message"}, cfg.Commands["notify"].Exec)
+	require.Equal(t, []string{"message"}, cfg.Commands["notify"].Arguments)
+
+	err = os.WriteFile(path, []byte(`{"commands": {"broken": {}}}`), 0600)
+	require.NoError(t, err)
+
+	_, err = Load(path)
+	require.Error(t, err)
+}
+
+func TestPath(t *testing.T) {
+	t.Setenv("RDM_CONFIG", "/tmp/rdm.json")
+	require.Equal(t, "/tmp/rdm.json", Path())
+
+	t.Setenv("RDM_CONFIG", "")
+	t.Setenv("XDG_CONFIG_HOME", "/tmp/config")
+	require.Equal(t, "/tmp/config/rdm/config.json", Path())
+}
+
+func TestLogPath(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_STATE_HOME", filepath.Join(dir, "state"))
+
+	cfg := &Config{}
+	path := cfg.LogPath("work")
+	require.Equal(t, "rdm-work.log", filepath.Base(path))
+
+	// Printing the path doesn't create the state directory.
+	require.NoDirExists(t, filepath.Dir(path))
+
+	cfg.Log.Path = "/var/log/rdm.log"
+	require.Equal(t, "/var/log/rdm.log", cfg.LogPath(""))
+}
+
+func TestLogRotation(t *testing.T) {
+	cfg := &Config{}
+	require.Equal(t, logging.Rotation{MaxSize: 10 << 20, MaxAge: 7 * 24 * time.Hour, MaxFiles: 5}, cfg.LogRotation())
+
+	cfg.Log = Log{MaxSizeMB: 1, MaxAgeDays: 1, MaxFiles: 2}
+	require.Equal(t, logging.Rotation{MaxSize: 1 << 20, MaxAge: 24 * time.Hour, MaxFiles: 2}, cfg.LogRotation())
+}
+
+func TestLimits(t *testing.T) {
+	cfg := &Config{
+		Commands: map[string]Command{"notify": {Exec: []string{"terminal-notifier"}}},
+		RateLimits: map[string]RateLimit{
+			"open":       {Burst: 10},
+			"paste":      {Burst: 1, CooldownSeconds: 0.5},
+			"relay-open": {Disabled: true},
+		},
+	}
+
+	require.Equal(t, map[string]ratelimit.Limit{
+		"open":   {Burst: 10, Cooldown: 2 * time.Second},
+		"notify": {Burst: 5, Cooldown: 2 * time.Second},
+		"paste":  {Burst: 1, Cooldown: 500 * time.Millisecond},
+	}, cfg.Limits())
+}
+
+func TestTimeouts(t *testing.T) {
+	cfg := &Config{
+		TimeoutSeconds: map[string]float64{
+			"copy":   0.5,
+			"open":   0,
+			"notify": 30,
+		},
+	}
+
+	require.Equal(t, map[string]time.Duration{
+		"copy":   500 * time.Millisecond,
+		"paste":  5 * time.Second,
+		"notify": 30 * time.Second,
+	}, cfg.Timeouts())
+}
+
+func TestSecretRules(t *testing.T) {
+	cfg := &Config{Secrets: Secrets{Patterns: map[string]string{"Slack token": `xox[bp]-[0-9A-Za-z-]+`}}}
+
+	rules, err := cfg.SecretRules()
+	require.NoError(t, err)
+	require.Len(t, rules, len(secrets.DefaultRules)+1)
+	require.Equal(t, []string{"Slack token"}, secrets.Scan(rules, "xoxb-1234-abcd"))
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"secrets": {"patterns": {"broken": "("}}}`), 0600))
+	_, err = Load(path)
+	require.Error(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"secrets": {"mode": "shout"}}`), 0600))
+	_, err = Load(path)
+	require.Error(t, err)
+}
+
+func TestLoad_Clipboard(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"clipboard": {"backend": "memory", "persist": true}}`), 0600))
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	require.Equal(t, ClipboardMemory, cfg.Clipboard.Backend)
+	require.True(t, cfg.Clipboard.Persist)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"clipboard": {"backend": "wayland"}}`), 0600))
+	_, err = Load(path)
+	require.Error(t, err)
+}
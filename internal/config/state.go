@@ -0,0 +1,28 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// StateDir returns the directory where the server keeps logs and other state,
+// creating it with permissions only allowing access by the current user.
+func StateDir() (string, error) {
+	dir := stateDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+
+	return dir, nil
+}
+
+// homeDir returns the home directory of the current user, falling back to the
+// temp directory if it can't be determined.
+func homeDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return os.TempDir()
+	}
+
+	return filepath.Clean(home)
+}
@@ -0,0 +1,11 @@
+//go:build darwin
+// +build darwin
+
+package config
+
+import "path/filepath"
+
+// stateDir returns ~/Library/Logs/rdm so logs show up in Console.app.
+func stateDir() string {
+	return filepath.Join(homeDir(), "Library", "Logs", "rdm")
+}
@@ -0,0 +1,18 @@
+//go:build linux
+// +build linux
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// stateDir returns $XDG_STATE_HOME/rdm, defaulting to ~/.local/state/rdm.
+func stateDir() string {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "rdm")
+	}
+
+	return filepath.Join(homeDir(), ".local", "state", "rdm")
+}
@@ -0,0 +1,94 @@
+package events
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// TypeClipboard is published when the contents of the host clipboard change.
+const TypeClipboard = "clipboard"
+
+// Event is a notification pushed from the server to subscribed clients.
+type Event struct {
+	Type string          `json:"type"`
+	Time time.Time       `json:"time"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// ClipboardData is the data of a TypeClipboard event.
+type ClipboardData struct {
+	Content string `json:"content"`
+}
+
+// New returns an event of the given type with data encoded as JSON.
+func New(eventType string, data interface{}) (Event, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, err
+	}
+
+	return Event{Type: eventType, Time: time.Now().UTC(), Data: encoded}, nil
+}
+
+// bufferSize is the number of events buffered per subscriber before events
+// are dropped for that subscriber.
+const bufferSize = 16
+
+// Bus fans out published events to every subscriber. It is safe for
+// concurrent use.
+type Bus struct {
+	mu          sync.Mutex
+	subscribers map[chan Event]struct{}
+}
+
+// NewBus returns a Bus with no subscribers.
+func NewBus() *Bus {
+	return &Bus{
+		subscribers: make(map[chan Event]struct{}),
+	}
+}
+
+// Subscribe returns a channel receiving every event published until the
+// returned function is called.
+func (b *Bus) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, bufferSize)
+
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, ch)
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+
+	return ch, unsubscribe
+}
+
+// Publish sends the event to every subscriber. Slow subscribers whose buffer
+// is full miss the event rather than blocking the publisher.
+func (b *Bus) Publish(event Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// Subscribers returns the number of active subscribers.
+func (b *Bus) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return len(b.subscribers)
+}
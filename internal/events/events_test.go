@@ -0,0 +1,44 @@
+package events
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBus(t *testing.T) {
+	bus := NewBus()
+
+	first, unsubscribeFirst := bus.Subscribe()
+	second, unsubscribeSecond := bus.Subscribe()
+	require.Equal(t, 2, bus.Subscribers())
+
+	event, err := New(TypeClipboard, ClipboardData{Content: "hello"})
+	require.NoError(t, err)
+	bus.Publish(event)
+
+	received := <-first
+	require.Equal(t, TypeClipboard, received.Type)
+
+	var data ClipboardData
+	require.NoError(t, json.Unmarshal(received.Data, &data))
+	require.Equal(t, "hello", data.Content)
+	require.Equal(t, event, <-second)
+
+	unsubscribeFirst()
+	unsubscribeFirst()
+	require.Equal(t, 1, bus.Subscribers())
+
+	_, open := <-first
+	require.False(t, open)
+
+	// Publishing to a full subscriber must not block.
+	for i := 0; i < bufferSize*2; i++ {
+		bus.Publish(event)
+	}
+	require.Len(t, second, bufferSize)
+
+	unsubscribeSecond()
+	require.Equal(t, 0, bus.Subscribers())
+}
@@ -0,0 +1,130 @@
+package expiry
+
+import (
+	"context"
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	"github.com/blakewilliams/remote-development-manager/internal/logging"
+)
+
+// Clipboard is the host clipboard values are cleared from.
+type Clipboard interface {
+	Copy(ctx context.Context, content string) error
+	Paste(ctx context.Context) ([]byte, error)
+}
+
+// clearTimeout bounds how long clearing a value may take, so a hung clipboard
+// command can't block Flush while the server shuts down.
+const clearTimeout = 5 * time.Second
+
+// Clearer clears values from the clipboard once they expire, as long as the
+// clipboard still holds them. Only hashes of pending values are kept. It is
+// safe for concurrent use.
+type Clearer struct {
+	clipboard Clipboard
+	logger    *logging.Logger
+
+	mu      sync.Mutex
+	pending map[[sha256.Size]byte]*time.Timer
+}
+
+// New returns a Clearer for clipboard.
+func New(clipboard Clipboard, logger *logging.Logger) *Clearer {
+	return &Clearer{
+		clipboard: clipboard,
+		logger:    logger,
+		pending:   make(map[[sha256.Size]byte]*time.Timer),
+	}
+}
+
+// ClearAfter clears content from the clipboard after ttl. Scheduling a value
+// that is already pending replaces its expiry, so the most recent copy wins.
+func (c *Clearer) ClearAfter(content string, ttl time.Duration) {
+	key := sha256.Sum256([]byte(content))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if timer, ok := c.pending[key]; ok {
+		timer.Stop()
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(ttl, func() {
+		c.mu.Lock()
+		// A newer timer for the same value may have replaced this one.
+		if c.pending[key] != timer {
+			c.mu.Unlock()
+			return
+		}
+		delete(c.pending, key)
+		c.mu.Unlock()
+
+		c.clear(key)
+	})
+	c.pending[key] = timer
+}
+
+// Cancel stops content from being cleared, e.g. because it was copied again
+// without an expiry.
+func (c *Clearer) Cancel(content string) {
+	key := sha256.Sum256([]byte(content))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if timer, ok := c.pending[key]; ok {
+		timer.Stop()
+		delete(c.pending, key)
+	}
+}
+
+// Pending returns the number of values waiting to be cleared.
+func (c *Clearer) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.pending)
+}
+
+// Flush immediately clears any pending value still on the clipboard, so they
+// don't outlive the server.
+func (c *Clearer) Flush() {
+	c.mu.Lock()
+	keys := make([][sha256.Size]byte, 0, len(c.pending))
+	for key, timer := range c.pending {
+		timer.Stop()
+		keys = append(keys, key)
+	}
+	c.pending = make(map[[sha256.Size]byte]*time.Timer)
+	c.mu.Unlock()
+
+	for _, key := range keys {
+		c.clear(key)
+	}
+}
+
+// clear empties the clipboard if it holds the value with the given hash.
+func (c *Clearer) clear(key [sha256.Size]byte) {
+	ctx, cancel := context.WithTimeout(context.Background(), clearTimeout)
+	defer cancel()
+
+	current, err := c.clipboard.Paste(ctx)
+	if err != nil {
+		c.logger.Error("could not read the clipboard to clear an expired value", "error", err)
+		return
+	}
+
+	if sha256.Sum256(current) != key {
+		return
+	}
+
+	if err := c.clipboard.Copy(ctx, ""); err != nil {
+		c.logger.Error("could not clear an expired value from the clipboard", "error", err)
+		return
+	}
+
+	c.logger.Info("cleared an expired value from the clipboard")
+}
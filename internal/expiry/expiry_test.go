@@ -0,0 +1,93 @@
+package expiry
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/blakewilliams/remote-development-manager/internal/logging"
+	"github.com/stretchr/testify/require"
+)
+
+type testClipboard struct {
+	mu     sync.Mutex
+	buffer string
+}
+
+func (c *testClipboard) Copy(ctx context.Context, content string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.buffer = content
+	return nil
+}
+
+func (c *testClipboard) Paste(ctx context.Context) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return []byte(c.buffer), nil
+}
+
+func (c *testClipboard) contents() string {
+	contents, _ := c.Paste(context.Background())
+	return string(contents)
+}
+
+func TestClearer(t *testing.T) {
+	clipboard := &testClipboard{}
+	clearer := New(clipboard, logging.Discard())
+
+	clipboard.Copy(context.Background(), "password")
+	clearer.ClearAfter("password", 10*time.Millisecond)
+
+	require.Eventually(t, func() bool { return clipboard.contents() == "" }, time.Second, time.Millisecond)
+	require.Equal(t, 0, clearer.Pending())
+}
+
+func TestClearer_Changed(t *testing.T) {
+	clipboard := &testClipboard{}
+	clearer := New(clipboard, logging.Discard())
+
+	// Overlapping copies each only clear their own value.
+	clearer.ClearAfter("first", 10*time.Millisecond)
+	clearer.ClearAfter("second", 20*time.Millisecond)
+	clipboard.Copy(context.Background(), "second")
+
+	require.Eventually(t, func() bool { return clearer.Pending() == 1 }, time.Second, time.Millisecond)
+	require.Equal(t, "second", clipboard.contents())
+
+	clipboard.Copy(context.Background(), "third")
+	require.Eventually(t, func() bool { return clearer.Pending() == 0 }, time.Second, time.Millisecond)
+	require.Equal(t, "third", clipboard.contents())
+}
+
+func TestClearer_Replace(t *testing.T) {
+	clipboard := &testClipboard{}
+	clearer := New(clipboard, logging.Discard())
+
+	clipboard.Copy(context.Background(), "password")
+	clearer.ClearAfter("password", 10*time.Millisecond)
+	clearer.ClearAfter("password", time.Hour)
+
+	time.Sleep(30 * time.Millisecond)
+	require.Equal(t, "password", clipboard.contents())
+	require.Equal(t, 1, clearer.Pending())
+
+	clearer.Cancel("password")
+	require.Equal(t, 0, clearer.Pending())
+}
+
+func TestClearer_Flush(t *testing.T) {
+	clipboard := &testClipboard{}
+	clearer := New(clipboard, logging.Discard())
+
+	clipboard.Copy(context.Background(), "password")
+	clearer.ClearAfter("password", time.Hour)
+	clearer.ClearAfter("other", time.Hour)
+
+	clearer.Flush()
+	require.Equal(t, "", clipboard.contents())
+	require.Equal(t, 0, clearer.Pending())
+}
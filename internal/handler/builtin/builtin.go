@@ -0,0 +1,160 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/blakewilliams/remote-development-manager/internal/expiry"
+	"github.com/blakewilliams/remote-development-manager/internal/handler"
+	"github.com/blakewilliams/remote-development-manager/internal/hostservice"
+)
+
+// Register adds the handlers backed by the host system to the registry.
+func Register(registry *handler.Registry, host hostservice.Runner, clearer *expiry.Clearer) error {
+	metadata := &Metadata{}
+	handlers := []*handler.Handler{
+		Copy(host, metadata, clearer),
+		Paste(host, metadata),
+		Open(host),
+	}
+
+	for _, h := range handlers {
+		if err := registry.Register(h); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Metadata remembers details about the last value copied through the server
+// that the host clipboard can't store, such as the Vim register type. It is
+// safe for concurrent use.
+type Metadata struct {
+	mu           sync.Mutex
+	content      string
+	registerType string
+}
+
+// Set records the register type of the copied content.
+func (m *Metadata) Set(content string, registerType string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.content = content
+	m.registerType = registerType
+}
+
+// RegisterType returns the register type recorded for content, or an empty
+// string if content is not the value that was last copied, e.g. because the
+// clipboard was changed on the host.
+func (m *Metadata) RegisterType(content string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.content != content {
+		return ""
+	}
+
+	return m.registerType
+}
+
+// PasteResult is the response of the paste command when the "format" option
+// is "json".
+type PasteResult struct {
+	Content      string `json:"content"`
+	RegisterType string `json:"regtype,omitempty"`
+}
+
+// Copy returns a handler that copies its argument to the host clipboard. The
+// "regtype" option records the Vim register type of the content, and the
+// "ttl" option clears the content from the clipboard after a duration.
+func Copy(host hostservice.Runner, metadata *Metadata, clearer *expiry.Clearer) *handler.Handler {
+	return &handler.Handler{
+		Name:      "copy",
+		Arguments: []handler.Argument{{Name: "content"}},
+		Options:   []string{"regtype", "ttl"},
+		Clipboard: handler.DirectionToHost,
+		Target: func(req *handler.Request) string {
+			return fmt.Sprintf("%d bytes", len(req.Arguments[0]))
+		},
+		Content: func(req *handler.Request, response []byte) string {
+			return req.Arguments[0]
+		},
+		Run: func(ctx context.Context, req *handler.Request) ([]byte, error) {
+			content := req.Arguments[0]
+
+			var ttl time.Duration
+			if value := req.Options["ttl"]; value != "" {
+				var err error
+				ttl, err = time.ParseDuration(value)
+				if err != nil || ttl <= 0 {
+					return nil, handler.Errorf(handler.CodeInvalid, "invalid ttl %q", value)
+				}
+			}
+
+			if err := host.Copy(ctx, content); err != nil {
+				return nil, err
+			}
+
+			// Copying a value again without a ttl keeps it on the clipboard.
+			if ttl > 0 {
+				clearer.ClearAfter(content, ttl)
+			} else {
+				clearer.Cancel(content)
+			}
+
+			metadata.Set(content, req.Options["regtype"])
+			return nil, nil
+		},
+	}
+}
+
+// Paste returns a handler that responds with the host clipboard contents.
+// When the "format" option is "json" the response is a PasteResult, including
+// the register type if the clipboard still holds the value copied with it.
+func Paste(host hostservice.Runner, metadata *Metadata) *handler.Handler {
+	return &handler.Handler{
+		Name:      "paste",
+		Options:   []string{"format"},
+		Clipboard: handler.DirectionFromHost,
+		Content: func(req *handler.Request, response []byte) string {
+			return string(response)
+		},
+		Run: func(ctx context.Context, req *handler.Request) ([]byte, error) {
+			contents, err := host.Paste(ctx)
+			if err != nil {
+				return nil, err
+			}
+
+			switch req.Options["format"] {
+			case "":
+				return contents, nil
+			case "json":
+				return json.Marshal(PasteResult{
+					Content:      string(contents),
+					RegisterType: metadata.RegisterType(string(contents)),
+				})
+			default:
+				return nil, handler.Errorf(handler.CodeInvalid, "unknown paste format %q", req.Options["format"])
+			}
+		},
+	}
+}
+
+// Open returns a handler that opens its argument on the host system.
+func Open(host hostservice.Runner) *handler.Handler {
+	return &handler.Handler{
+		Name:      "open",
+		Arguments: []handler.Argument{{Name: "target"}},
+		Target: func(req *handler.Request) string {
+			return req.Arguments[0]
+		},
+		Run: func(ctx context.Context, req *handler.Request) ([]byte, error) {
+			return nil, host.Open(ctx, req.Arguments[0])
+		},
+	}
+}
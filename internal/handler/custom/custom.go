@@ -0,0 +1,55 @@
+package custom
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/blakewilliams/remote-development-manager/internal/config"
+	"github.com/blakewilliams/remote-development-manager/internal/handler"
+)
+
+// Register adds a handler for each command in the config.
+func Register(registry handler.Registerer, commands map[string]config.Command) error {
+	for name, command := range commands {
+		if err := registry.Register(New(name, command)); err != nil {
+			return fmt.Errorf("could not register custom command: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// New returns a handler that runs the configured program with the client's
+// arguments appended, responding with its stdout.
+func New(name string, command config.Command) *handler.Handler {
+	arguments := make([]handler.Argument, 0, len(command.Arguments))
+	for _, argument := range command.Arguments {
+		arguments = append(arguments, handler.Argument{Name: argument})
+	}
+
+	return &handler.Handler{
+		Name:      name,
+		Arguments: arguments,
+		Variadic:  command.Variadic,
+		Target: func(req *handler.Request) string {
+			return strings.Join(command.Exec, " ")
+		},
+		Run: func(ctx context.Context, req *handler.Request) ([]byte, error) {
+			argv := append(append([]string{}, command.Exec[1:]...), req.Arguments...)
+			cmd := exec.CommandContext(ctx, command.Exec[0], argv...)
+
+			var stderr bytes.Buffer
+			cmd.Stderr = &stderr
+
+			output, err := cmd.Output()
+			if err != nil {
+				return nil, fmt.Errorf("could not run %v: %w: %s", command.Exec[0], err, strings.TrimSpace(stderr.String()))
+			}
+
+			return output, nil
+		},
+	}
+}
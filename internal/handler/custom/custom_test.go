@@ -0,0 +1,30 @@
+package custom
+
+import (
+	"context"
+	"testing"
+
+	"github.com/blakewilliams/remote-development-manager/internal/config"
+	"github.com/blakewilliams/remote-development-manager/internal/handler"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegister(t *testing.T) {
+	registry := handler.NewRegistry()
+
+	err := Register(registry, map[string]config.Command{
+		"greet": {Exec: []string{"echo", "hello"}, Arguments: []string{"name"}},
+		"fail":  {Exec: []string{"false"}},
+	})
+	require.NoError(t, err)
+
+	result, err := registry.Dispatch(context.Background(), &handler.Request{Name: "greet", Arguments: []string{"world"}})
+	require.NoError(t, err)
+	require.Equal(t, "hello world\n", string(result))
+
+	_, err = registry.Dispatch(context.Background(), &handler.Request{Name: "greet"})
+	require.Equal(t, handler.CodeInvalid, handler.CodeOf(err))
+
+	_, err = registry.Dispatch(context.Background(), &handler.Request{Name: "fail"})
+	require.Error(t, err)
+}
@@ -0,0 +1,85 @@
+package edit
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/blakewilliams/remote-development-manager/internal/handler"
+)
+
+// Editor opens a file on the host and blocks until editing is done. The
+// returned channel is closed once the editor no longer uses the file.
+type Editor interface {
+	Edit(ctx context.Context, path string) (<-chan struct{}, error)
+}
+
+// New returns a handler that writes the base64 encoded content sent by the
+// client to a temporary file, opens it in the editor and responds with the
+// edited content.
+func New(editor Editor) *handler.Handler {
+	return &handler.Handler{
+		Name:        "edit",
+		Arguments:   []handler.Argument{{Name: "name"}, {Name: "content"}},
+		LongRunning: true,
+		Target: func(req *handler.Request) string {
+			return req.Arguments[0]
+		},
+		Run: func(ctx context.Context, req *handler.Request) ([]byte, error) {
+			content, err := base64.StdEncoding.DecodeString(req.Arguments[1])
+			if err != nil {
+				return nil, handler.Errorf(handler.CodeInvalid, "edit content must be base64 encoded: %v", err)
+			}
+
+			// The file keeps its name so editors can detect the file type,
+			// but is written to a directory only the current user can read.
+			dir, err := os.MkdirTemp("", "rdm-edit-")
+			if err != nil {
+				return nil, fmt.Errorf("could not create directory for edit: %w", err)
+			}
+
+			path := filepath.Join(dir, fileName(req.Arguments[0]))
+			if err := os.WriteFile(path, content, 0600); err != nil {
+				os.RemoveAll(dir)
+				return nil, fmt.Errorf("could not write file for edit: %w", err)
+			}
+
+			exited, err := editor.Edit(ctx, path)
+			var edited []byte
+			if err == nil {
+				edited, err = os.ReadFile(path)
+				if err != nil {
+					err = fmt.Errorf("could not read edited file: %w", err)
+				}
+			}
+
+			// The editor may still be running when waiting for a save or when
+			// the request is cancelled, so the file is removed once it exits.
+			go func() {
+				if exited != nil {
+					<-exited
+				}
+				os.RemoveAll(dir)
+			}()
+
+			if err != nil {
+				return nil, err
+			}
+
+			return edited, nil
+		},
+	}
+}
+
+// fileName returns the base name of the remote file, ignoring any directories
+// so it can't be written outside of the temporary directory.
+func fileName(name string) string {
+	base := filepath.Base(name)
+	if base == "." || base == ".." || base == string(filepath.Separator) {
+		return "file"
+	}
+
+	return base
+}
@@ -0,0 +1,102 @@
+package edit
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blakewilliams/remote-development-manager/internal/handler"
+	"github.com/stretchr/testify/require"
+)
+
+type appendEditor struct {
+	path string
+}
+
+func (e *appendEditor) Edit(ctx context.Context, path string) (<-chan struct{}, error) {
+	e.path = path
+
+	exited := make(chan struct{})
+	close(exited)
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return exited, err
+	}
+
+	return exited, os.WriteFile(path, append(content, []byte(" edited")...), 0600)
+}
+
+// runningEditor returns once the file is opened, leaving the editor running
+// until exit is closed.
+type runningEditor struct {
+	path string
+	exit chan struct{}
+}
+
+func (e *runningEditor) Edit(ctx context.Context, path string) (<-chan struct{}, error) {
+	e.path = path
+	return e.exit, nil
+}
+
+func TestEdit(t *testing.T) {
+	editor := &appendEditor{}
+	h := New(editor)
+
+	result, err := h.Run(context.Background(), &handler.Request{Arguments: []string{"/home/blake/../COMMIT_EDITMSG", base64.StdEncoding.EncodeToString([]byte("message"))}})
+	require.NoError(t, err)
+	require.Equal(t, "message edited", string(result))
+
+	require.Equal(t, "COMMIT_EDITMSG", filepath.Base(editor.path))
+	require.True(t, strings.HasPrefix(filepath.Base(filepath.Dir(editor.path)), "rdm-edit-"))
+
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(editor.path)
+		return os.IsNotExist(err)
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestEdit_EditorStillRunning(t *testing.T) {
+	editor := &runningEditor{exit: make(chan struct{})}
+	h := New(editor)
+
+	_, err := h.Run(context.Background(), &handler.Request{Arguments: []string{"notes.txt", base64.StdEncoding.EncodeToString([]byte("notes"))}})
+	require.NoError(t, err)
+
+	// The file is kept while the editor is running.
+	require.FileExists(t, editor.path)
+
+	close(editor.exit)
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(editor.path)
+		return os.IsNotExist(err)
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestEdit_Binary(t *testing.T) {
+	h := New(&appendEditor{})
+
+	// Latin-1 encoded files aren't valid UTF-8 and must arrive unchanged.
+	content := []byte("caf\xe9 \xff\x00")
+	result, err := h.Run(context.Background(), &handler.Request{Arguments: []string{"notes.txt", base64.StdEncoding.EncodeToString(content)}})
+	require.NoError(t, err)
+	require.Equal(t, append(content, []byte(" edited")...), result)
+}
+
+func TestEdit_InvalidContent(t *testing.T) {
+	h := New(&appendEditor{})
+
+	_, err := h.Run(context.Background(), &handler.Request{Arguments: []string{"notes.txt", "not base64!"}})
+	require.Error(t, err)
+	require.Equal(t, handler.CodeInvalid, handler.CodeOf(err))
+}
+
+func TestFileName(t *testing.T) {
+	require.Equal(t, "main.go", fileName("src/main.go"))
+	require.Equal(t, "file", fileName("/"))
+	require.Equal(t, "file", fileName(".."))
+}
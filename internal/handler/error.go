@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Code categorizes handler errors so they can be reported to clients.
+type Code string
+
+const (
+	// CodeFailed is used for errors that have no more specific code.
+	CodeFailed Code = "failed"
+	// CodeNotFound means no handler is registered for the command.
+	CodeNotFound Code = "not_found"
+	// CodeInvalid means the request did not match the handler's schema.
+	CodeInvalid Code = "invalid_argument"
+	// CodeDenied means the user on the host denied the request.
+	CodeDenied Code = "denied"
+	// CodeRateLimited means the client sent the command too often.
+	CodeRateLimited Code = "rate_limited"
+	// CodeTimeout means the command did not finish within its timeout.
+	CodeTimeout Code = "timeout"
+)
+
+// Error is an error with an associated Code.
+type Error struct {
+	Code Code
+	Err  error
+}
+
+// Errorf returns an Error with the given code and formatted message.
+func Errorf(code Code, format string, args ...interface{}) error {
+	return &Error{Code: code, Err: fmt.Errorf(format, args...)}
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// CodeOf returns the code of the first Error in err's chain, or CodeFailed if
+// there is none.
+func CodeOf(err error) Code {
+	var handlerErr *Error
+	if errors.As(err, &handlerErr) {
+		return handlerErr.Code
+	}
+
+	return CodeFailed
+}
@@ -0,0 +1,200 @@
+package gitcredential
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blakewilliams/remote-development-manager/internal/handler"
+	"github.com/blakewilliams/remote-development-manager/internal/hostservice/confirm"
+)
+
+// subcommands maps credential helper actions to `git credential` subcommands.
+var subcommands = map[string]string{
+	"get":   "fill",
+	"store": "approve",
+	"erase": "reject",
+}
+
+// attributeNames are the attributes passed to git: those described in the
+// confirmation dialog and the password being stored or erased. Others, like
+// url, would override what the user confirmed.
+var attributeNames = []string{"protocol", "host", "path", "username", "password"}
+
+// rememberFor is how long a credential returned by get may be stored without
+// another confirmation. Git stores credentials after they are used
+// successfully, so this avoids asking twice for a single push.
+const rememberFor = 10 * time.Minute
+
+// GitFunc runs `git credential <subcommand>` with input on stdin.
+type GitFunc func(ctx context.Context, subcommand string, input string) ([]byte, error)
+
+type helper struct {
+	confirm confirm.Func
+	git     GitFunc
+	now     func() time.Time
+
+	mu sync.Mutex
+	// filled holds a hash of each credential returned by get, so the value
+	// itself is never kept in memory.
+	filled map[[sha256.Size]byte]time.Time
+}
+
+// New returns a handler implementing the git credential helper protocol using
+// the credential helpers configured for git on the host. Every request must be
+// confirmed by the user on the host, except storing a credential that was
+// just returned by get.
+func New(confirm confirm.Func) *handler.Handler {
+	return newHandler(confirm, runGit)
+}
+
+func newHandler(confirm confirm.Func, git GitFunc) *handler.Handler {
+	h := &helper{
+		confirm: confirm,
+		git:     git,
+		now:     time.Now,
+		filled:  make(map[[sha256.Size]byte]time.Time),
+	}
+
+	return &handler.Handler{
+		Name:        "git-credential",
+		Arguments:   []handler.Argument{{Name: "action"}, {Name: "input"}},
+		Run:         h.run,
+		LongRunning: true,
+		Target: func(req *handler.Request) string {
+			return req.Arguments[0] + " " + describe(parse(req.Arguments[1]))
+		},
+	}
+}
+
+func (h *helper) run(ctx context.Context, req *handler.Request) ([]byte, error) {
+	action, input := req.Arguments[0], req.Arguments[1]
+
+	subcommand, ok := subcommands[action]
+	if !ok {
+		return nil, handler.Errorf(handler.CodeInvalid, "unknown git credential action %q", action)
+	}
+
+	attributes := parse(input)
+
+	if action != "store" || !h.recentlyFilled(attributes) {
+		verb := map[string]string{"get": "read", "store": "store", "erase": "erase"}[action]
+		message := fmt.Sprintf("%s wants to %s your git credentials for %s.", req.Identity, verb, describe(attributes))
+
+		allowed, err := h.confirm(ctx, message)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, handler.Errorf(handler.CodeDenied, "git credential %s was denied on the host", action)
+		}
+	}
+
+	output, err := h.git(ctx, subcommand, format(attributes))
+	if err != nil {
+		return nil, err
+	}
+
+	if action == "get" {
+		h.remember(parse(string(output)))
+	}
+
+	return output, nil
+}
+
+func (h *helper) remember(attributes map[string]string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := h.now()
+	for key, filledAt := range h.filled {
+		if now.Sub(filledAt) > rememberFor {
+			delete(h.filled, key)
+		}
+	}
+
+	h.filled[credentialKey(attributes)] = now
+}
+
+func (h *helper) recentlyFilled(attributes map[string]string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	filledAt, ok := h.filled[credentialKey(attributes)]
+	return ok && h.now().Sub(filledAt) <= rememberFor
+}
+
+// credentialKey identifies a credential without retaining the password.
+func credentialKey(attributes map[string]string) [sha256.Size]byte {
+	var key strings.Builder
+	for _, name := range attributeNames {
+		key.WriteString(attributes[name])
+		key.WriteByte(0)
+	}
+
+	return sha256.Sum256([]byte(key.String()))
+}
+
+// parse reads the key=value lines of the git credential protocol.
+func parse(input string) map[string]string {
+	attributes := make(map[string]string)
+
+	for _, line := range strings.Split(input, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSuffix(line, "\r"), "=")
+		if ok {
+			attributes[key] = value
+		}
+	}
+
+	return attributes
+}
+
+// describe returns the URL the credential is for, e.g. https://github.com.
+func describe(attributes map[string]string) string {
+	description := attributes["protocol"] + "://" + attributes["host"]
+	if attributes["path"] != "" {
+		description += "/" + attributes["path"]
+	}
+
+	if attributes["username"] != "" {
+		description += fmt.Sprintf(" (user %s)", attributes["username"])
+	}
+
+	return description
+}
+
+// format writes the attributes passed to git in the git credential protocol,
+// leaving out any others.
+func format(attributes map[string]string) string {
+	var input strings.Builder
+	for _, name := range attributeNames {
+		if value, ok := attributes[name]; ok {
+			fmt.Fprintf(&input, "%s=%s\n", name, value)
+		}
+	}
+
+	return input.String()
+}
+
+func runGit(ctx context.Context, subcommand string, input string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", "credential", subcommand)
+	cmd.Stdin = strings.NewReader(input)
+	// The server has no terminal, so git must never prompt for credentials.
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not run git credential %s: %w: %s", subcommand, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return output, nil
+}
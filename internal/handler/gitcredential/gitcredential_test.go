@@ -0,0 +1,68 @@
+package gitcredential
+
+import (
+	"context"
+	"testing"
+
+	"github.com/blakewilliams/remote-development-manager/internal/client"
+	"github.com/blakewilliams/remote-development-manager/internal/handler"
+	"github.com/stretchr/testify/require"
+)
+
+const request = "protocol=https\nhost=github.com\n\n"
+const credential = "protocol=https\nhost=github.com\nusername=blake\npassword=hunter2\n"
+
+func TestGitCredential(t *testing.T) {
+	var prompts []string
+	allow := true
+	confirmer := func(ctx context.Context, message string) (bool, error) {
+		prompts = append(prompts, message)
+		return allow, nil
+	}
+
+	var ran []string
+	var inputs []string
+	git := func(ctx context.Context, subcommand string, input string) ([]byte, error) {
+		ran = append(ran, subcommand)
+		inputs = append(inputs, input)
+		if subcommand == "fill" {
+			return []byte(credential), nil
+		}
+		return nil, nil
+	}
+
+	h := newHandler(confirmer, git)
+	identity := client.Identity{Label: "codespace-foo"}
+
+	result, err := h.Run(context.Background(), &handler.Request{Arguments: []string{"get", request}, Identity: identity})
+	require.NoError(t, err)
+	require.Equal(t, credential, string(result))
+	require.Equal(t, []string{"codespace-foo wants to read your git credentials for https://github.com."}, prompts)
+
+	// Storing the credential that was just returned does not ask again.
+	_, err = h.Run(context.Background(), &handler.Request{Arguments: []string{"store", credential}, Identity: identity})
+	require.NoError(t, err)
+	require.Len(t, prompts, 1)
+
+	// Storing a different credential must be confirmed.
+	_, err = h.Run(context.Background(), &handler.Request{Arguments: []string{"store", credential + "password=other\n"}, Identity: identity})
+	require.NoError(t, err)
+	require.Len(t, prompts, 2)
+
+	allow = false
+	_, err = h.Run(context.Background(), &handler.Request{Arguments: []string{"erase", credential}, Identity: identity})
+	require.Equal(t, handler.CodeDenied, handler.CodeOf(err))
+	require.Equal(t, "codespace-foo wants to erase your git credentials for https://github.com (user blake).", prompts[2])
+
+	require.Equal(t, []string{"fill", "approve", "approve"}, ran)
+
+	_, err = h.Run(context.Background(), &handler.Request{Arguments: []string{"unknown", request}})
+	require.Equal(t, handler.CodeInvalid, handler.CodeOf(err))
+
+	// Only the confirmed attributes reach git, so url can't override them.
+	allow = true
+	_, err = h.Run(context.Background(), &handler.Request{Arguments: []string{"get", request + "url=https://evil.example.com\nwwwauth[]=Basic\n"}, Identity: identity})
+	require.NoError(t, err)
+	require.Equal(t, "codespace-foo wants to read your git credentials for https://github.com.", prompts[3])
+	require.Equal(t, "protocol=https\nhost=github.com\n", inputs[len(inputs)-1])
+}
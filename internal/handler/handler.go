@@ -0,0 +1,209 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/blakewilliams/remote-development-manager/internal/client"
+)
+
+// Request is a single command invocation received by the server.
+type Request struct {
+	Name      string
+	Arguments []string
+	Options   map[string]string
+	// Identity is the client that sent the request. It is empty for clients
+	// that predate identities.
+	Identity client.Identity
+	// Peer is the process connected to the server's socket, or nil when it
+	// isn't known.
+	Peer *Peer
+}
+
+// Peer is a local process connected to the server's unix socket. For remote
+// clients this is the process forwarding the connection, such as sshd.
+type Peer struct {
+	PID int `json:"pid"`
+	UID int `json:"uid"`
+}
+
+// Func runs a command and returns the output to send back to the client.
+type Func func(ctx context.Context, req *Request) ([]byte, error)
+
+// Argument describes a positional argument accepted by a handler.
+type Argument struct {
+	Name     string
+	Optional bool
+}
+
+// Handler describes a command that can be run by the server.
+type Handler struct {
+	Name string
+	// Arguments is the schema for the positional arguments of the command.
+	Arguments []Argument
+	// Variadic allows any number of arguments after those in Arguments.
+	Variadic bool
+	// Options are the names of the options accepted by the command.
+	Options []string
+	Run     Func
+	// Target describes what a request acts on for the audit log, such as a
+	// URL. It must not include sensitive values like clipboard contents.
+	Target func(req *Request) string
+	// Content returns the clipboard contents involved in a request, which is
+	// only recorded in the audit log when explicitly configured.
+	Content func(req *Request, response []byte) string
+	// Unaudited handlers only report on the server itself and are left out of
+	// the audit log.
+	Unaudited bool
+	// Clipboard is the direction clipboard contents move in for handlers that
+	// copy to or paste from the host. Their Content is scanned for secrets.
+	Clipboard Direction
+	// LongRunning handlers may wait on the user at the host, e.g. for an
+	// editor or a confirmation dialog, so their responses aren't limited by
+	// the server's write timeout.
+	LongRunning bool
+}
+
+// Direction is the way clipboard contents move between the client and host.
+type Direction int
+
+const (
+	DirectionNone Direction = iota
+	// DirectionToHost handlers receive contents from the client, e.g. copy.
+	DirectionToHost
+	// DirectionFromHost handlers return contents to the client, e.g. paste.
+	DirectionFromHost
+)
+
+// Validate returns an error if the request does not match the argument schema
+// of the handler.
+func (h *Handler) Validate(req *Request) error {
+	required := 0
+	for _, argument := range h.Arguments {
+		if !argument.Optional {
+			required++
+		}
+	}
+
+	if len(req.Arguments) < required {
+		return Errorf(CodeInvalid, "%s requires argument %q", h.Name, h.Arguments[len(req.Arguments)].Name)
+	}
+
+	if !h.Variadic && len(req.Arguments) > len(h.Arguments) {
+		return Errorf(CodeInvalid, "%s accepts at most %d arguments, got %d", h.Name, len(h.Arguments), len(req.Arguments))
+	}
+
+	for option := range req.Options {
+		if !h.acceptsOption(option) {
+			return Errorf(CodeInvalid, "%s does not accept option %q", h.Name, option)
+		}
+	}
+
+	return nil
+}
+
+func (h *Handler) acceptsOption(option string) bool {
+	for _, accepted := range h.Options {
+		if accepted == option {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Registerer is implemented by anything handlers can be registered with, such
+// as Registry or the server.
+type Registerer interface {
+	Register(*Handler) error
+}
+
+// Registry holds the handlers available to the server, keyed by name.
+type Registry struct {
+	mu       sync.RWMutex
+	handlers map[string]*Handler
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		handlers: make(map[string]*Handler),
+	}
+}
+
+// Register adds a handler to the registry. It is an error to register two
+// handlers with the same name.
+func (r *Registry) Register(h *Handler) error {
+	if h.Name == "" {
+		return fmt.Errorf("handler name is required")
+	}
+
+	if h.Run == nil {
+		return fmt.Errorf("handler %s has no function", h.Name)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.handlers[h.Name]; ok {
+		return fmt.Errorf("handler %s is already registered", h.Name)
+	}
+	r.handlers[h.Name] = h
+
+	return nil
+}
+
+// Lookup returns the handler registered with the given name.
+func (r *Registry) Lookup(name string) (*Handler, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	h, ok := r.handlers[name]
+	return h, ok
+}
+
+// Names returns the names of every registered handler in sorted order.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.handlers))
+	for name := range r.handlers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Capabilities returns the options accepted by each registered handler, keyed
+// by handler name.
+func (r *Registry) Capabilities() map[string][]string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	capabilities := make(map[string][]string, len(r.handlers))
+	for name, h := range r.handlers {
+		options := append([]string{}, h.Options...)
+		sort.Strings(options)
+		capabilities[name] = options
+	}
+
+	return capabilities
+}
+
+// Dispatch validates the request against the matching handler and runs it.
+func (r *Registry) Dispatch(ctx context.Context, req *Request) ([]byte, error) {
+	h, ok := r.Lookup(req.Name)
+	if !ok {
+		return nil, Errorf(CodeNotFound, "command not found: %s", req.Name)
+	}
+
+	if err := h.Validate(req); err != nil {
+		return nil, err
+	}
+
+	return h.Run(ctx, req)
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func echoHandler(name string) *Handler {
+	return &Handler{
+		Name:      name,
+		Arguments: []Argument{{Name: "message"}, {Name: "suffix", Optional: true}},
+		Options:   []string{"upcase"},
+		Run: func(ctx context.Context, req *Request) ([]byte, error) {
+			return []byte(req.Arguments[0]), nil
+		},
+	}
+}
+
+func TestRegistry_Register(t *testing.T) {
+	registry := NewRegistry()
+
+	require.NoError(t, registry.Register(echoHandler("echo")))
+	require.Error(t, registry.Register(echoHandler("echo")))
+	require.Error(t, registry.Register(&Handler{Name: "nothing"}))
+
+	require.Equal(t, []string{"echo"}, registry.Names())
+	require.Equal(t, map[string][]string{"echo": {"upcase"}}, registry.Capabilities())
+}
+
+func TestRegistry_Dispatch(t *testing.T) {
+	registry := NewRegistry()
+	require.NoError(t, registry.Register(echoHandler("echo")))
+
+	testCases := map[string]struct {
+		request  Request
+		expected string
+		code     Code
+	}{
+		"valid":             {request: Request{Name: "echo", Arguments: []string{"hi"}}, expected: "hi"},
+		"optional argument": {request: Request{Name: "echo", Arguments: []string{"hi", "!"}}, expected: "hi"},
+		"known option":      {request: Request{Name: "echo", Arguments: []string{"hi"}, Options: map[string]string{"upcase": "true"}}, expected: "hi"},
+		"unknown command":   {request: Request{Name: "missing"}, code: CodeNotFound},
+		"missing argument":  {request: Request{Name: "echo"}, code: CodeInvalid},
+		"extra argument":    {request: Request{Name: "echo", Arguments: []string{"a", "b", "c"}}, code: CodeInvalid},
+		"unknown option":    {request: Request{Name: "echo", Arguments: []string{"hi"}, Options: map[string]string{"ttl": "1s"}}, code: CodeInvalid},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			result, err := registry.Dispatch(context.Background(), &tc.request)
+
+			if tc.code != "" {
+				require.Error(t, err)
+				require.Equal(t, tc.code, CodeOf(err))
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, string(result))
+		})
+	}
+}
@@ -0,0 +1,326 @@
+package oauthrelay
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/blakewilliams/remote-development-manager/internal/handler"
+	"github.com/blakewilliams/remote-development-manager/internal/hostservice/open"
+)
+
+// CallbackTimeout is how long the host waits for the browser to be
+// redirected to the callback URL.
+const CallbackTimeout = 5 * time.Minute
+
+// responseTimeout is how long the browser waits for the remote callback
+// server's response before a generic page is shown.
+const responseTimeout = 30 * time.Second
+
+// Request is a callback request received by the host, to be replayed against
+// the callback server on the remote machine.
+type Request struct {
+	ID         string      `json:"id"`
+	Method     string      `json:"method"`
+	RequestURI string      `json:"request_uri"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+}
+
+// Response is the response of the remote callback server, to be sent to the
+// browser on the host.
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+}
+
+// hopHeaders are not forwarded between the host and remote connections.
+var hopHeaders = []string{"Connection", "Content-Length", "Keep-Alive", "Transfer-Encoding", "Upgrade"}
+
+// minCallbackPort is the lowest callback port the host listens on, leaving out
+// privileged ports.
+const minCallbackPort = 1024
+
+// CallbackPort returns the port of a localhost redirect_uri in target, as used
+// by OAuth flows in command line tools. Privileged ports aren't relayed.
+func CallbackPort(target string) (int, bool) {
+	parsed, err := url.Parse(target)
+	if err != nil {
+		return 0, false
+	}
+
+	redirect, err := url.Parse(parsed.Query().Get("redirect_uri"))
+	if err != nil || redirect.Scheme != "http" {
+		return 0, false
+	}
+
+	switch redirect.Hostname() {
+	case "localhost", "127.0.0.1", "::1":
+	default:
+		return 0, false
+	}
+
+	port, err := strconv.Atoi(redirect.Port())
+	if err != nil || port < minCallbackPort || port > 65535 {
+		return 0, false
+	}
+
+	return port, true
+}
+
+// Relay opens URLs on the host while listening for their OAuth callback,
+// handing the callback to the remote client and the remote response back to
+// the browser.
+type Relay struct {
+	opener open.Opener
+
+	mu      sync.Mutex
+	pending map[string]chan *Response
+}
+
+// Register adds the relay-open and relay-response handlers.
+func Register(registry handler.Registerer, opener open.Opener) error {
+	relay := &Relay{
+		opener:  opener,
+		pending: make(map[string]chan *Response),
+	}
+
+	handlers := []*handler.Handler{
+		{
+			Name:        "relay-open",
+			Arguments:   []handler.Argument{{Name: "target"}, {Name: "port"}},
+			Run:         relay.open,
+			LongRunning: true,
+			Target: func(req *handler.Request) string {
+				return req.Arguments[0]
+			},
+		},
+		{
+			Name:      "relay-response",
+			Arguments: []handler.Argument{{Name: "id"}, {Name: "response"}},
+			Run:       relay.respond,
+			Target: func(req *handler.Request) string {
+				return req.Arguments[0]
+			},
+		},
+	}
+
+	for _, h := range handlers {
+		if err := registry.Register(h); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// open listens on the callback port on the host, opens the target and
+// responds with the first callback request received. The port must be the one
+// the target redirects to, so clients can't make the host listen on any port.
+func (r *Relay) open(ctx context.Context, req *handler.Request) ([]byte, error) {
+	target := req.Arguments[0]
+	port, err := strconv.Atoi(req.Arguments[1])
+	if err != nil {
+		return nil, handler.Errorf(handler.CodeInvalid, "invalid callback port %q", req.Arguments[1])
+	}
+
+	expected, ok := CallbackPort(target)
+	if !ok {
+		return nil, handler.Errorf(handler.CodeInvalid, "%s has no localhost redirect_uri to relay", target)
+	}
+	if port != expected {
+		return nil, handler.Errorf(handler.CodeInvalid, "callback port %d does not match the redirect_uri port %d", port, expected)
+	}
+
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+
+	captured := make(chan *Request, 1)
+	responses := make(chan *Response, 1)
+	served := make(chan struct{})
+
+	r.mu.Lock()
+	r.pending[id] = responses
+	r.mu.Unlock()
+
+	callbackServer := &http.Server{
+		Handler: http.HandlerFunc(func(rw http.ResponseWriter, hr *http.Request) {
+			if hr.URL.Path == "/favicon.ico" {
+				http.NotFound(rw, hr)
+				return
+			}
+
+			body, _ := io.ReadAll(hr.Body)
+			header := hr.Header.Clone()
+			for _, name := range hopHeaders {
+				header.Del(name)
+			}
+
+			select {
+			case captured <- &Request{ID: id, Method: hr.Method, RequestURI: hr.RequestURI, Header: header, Body: body}:
+			default:
+				http.Error(rw, "the callback was already received", http.StatusConflict)
+				return
+			}
+			defer close(served)
+
+			select {
+			case response := <-responses:
+				for name, values := range response.Header {
+					rw.Header()[name] = values
+				}
+				rw.WriteHeader(response.StatusCode)
+				rw.Write(response.Body)
+			case <-time.After(responseTimeout):
+				fmt.Fprintln(rw, "The login was sent to the remote machine. You can close this window.")
+			}
+		}),
+	}
+
+	// done stops the callback server and forgets the pending callback.
+	done := func() {
+		callbackServer.Close()
+
+		r.mu.Lock()
+		delete(r.pending, id)
+		r.mu.Unlock()
+	}
+
+	if err := listen(callbackServer, port); err != nil {
+		done()
+		return nil, err
+	}
+
+	if err := r.opener.Open(ctx, target); err != nil {
+		done()
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, CallbackTimeout)
+	defer cancel()
+
+	select {
+	case callback := <-captured:
+		// Keep serving until the browser has received the relayed response.
+		go func() {
+			select {
+			case <-served:
+			case <-time.After(responseTimeout * 2):
+			}
+
+			// Shut down gracefully so the response is flushed to the browser.
+			shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer shutdownCancel()
+			callbackServer.Shutdown(shutdownCtx)
+			done()
+		}()
+
+		return json.Marshal(callback)
+	case <-ctx.Done():
+		done()
+		return nil, fmt.Errorf("timed out waiting for the callback to localhost:%d", port)
+	}
+}
+
+// respond delivers the remote callback server's response to the waiting
+// browser.
+func (r *Relay) respond(ctx context.Context, req *handler.Request) ([]byte, error) {
+	var response Response
+	if err := json.Unmarshal([]byte(req.Arguments[1]), &response); err != nil {
+		return nil, handler.Errorf(handler.CodeInvalid, "could not parse relayed response: %w", err)
+	}
+
+	r.mu.Lock()
+	responses, ok := r.pending[req.Arguments[0]]
+	r.mu.Unlock()
+
+	if !ok {
+		return nil, handler.Errorf(handler.CodeNotFound, "no pending callback with id %s", req.Arguments[0])
+	}
+
+	select {
+	case responses <- &response:
+	default:
+	}
+
+	return nil, nil
+}
+
+// listen serves the callback server on the port for both IPv4 and IPv6
+// loopback addresses, since browsers may resolve localhost to either. Only the
+// IPv4 listener is required.
+func listen(server *http.Server, port int) error {
+	listener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+	if err != nil {
+		return fmt.Errorf("could not listen for callback on port %d: %w", port, err)
+	}
+	go server.Serve(listener)
+
+	if listener, err := net.Listen("tcp", net.JoinHostPort("::1", strconv.Itoa(port))); err == nil {
+		go server.Serve(listener)
+	}
+
+	return nil
+}
+
+// Replay sends the callback request to the callback server listening on port
+// on this machine, returning its response. Redirects are returned rather than
+// followed so the browser can follow them.
+func Replay(ctx context.Context, port int, callback *Request) (*Response, error) {
+	request, err := http.NewRequestWithContext(
+		ctx,
+		callback.Method,
+		fmt.Sprintf("http://localhost:%d%s", port, callback.RequestURI),
+		bytes.NewReader(callback.Body),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not create callback request: %w", err)
+	}
+	request.Header = callback.Header.Clone()
+
+	httpClient := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("could not send callback to localhost:%d: %w", port, err)
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not read callback response: %w", err)
+	}
+
+	header := response.Header.Clone()
+	for _, name := range hopHeaders {
+		header.Del(name)
+	}
+
+	return &Response{StatusCode: response.StatusCode, Header: header, Body: body}, nil
+}
+
+func newID() (string, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("could not generate relay id: %w", err)
+	}
+
+	return hex.EncodeToString(id), nil
+}
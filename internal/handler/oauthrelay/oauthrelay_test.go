@@ -0,0 +1,138 @@
+package oauthrelay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/blakewilliams/remote-development-manager/internal/handler"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallbackPort(t *testing.T) {
+	testCases := map[string]struct {
+		target string
+		port   int
+		ok     bool
+	}{
+		"localhost":      {target: "https://example.com/auth?redirect_uri=http%3A%2F%2Flocalhost%3A8085%2Fcallback", port: 8085, ok: true},
+		"loopback":       {target: "https://example.com/auth?redirect_uri=http://127.0.0.1:9000/", port: 9000, ok: true},
+		"remote host":    {target: "https://example.com/auth?redirect_uri=https%3A%2F%2Fexample.com%2Fcallback"},
+		"no port":        {target: "https://example.com/auth?redirect_uri=http%3A%2F%2Flocalhost%2Fcallback"},
+		"no redirect":    {target: "https://github.com/blakewilliams/remote-development-manager"},
+		"invalid port":   {target: "https://example.com/auth?redirect_uri=http%3A%2F%2Flocalhost%3A99999"},
+		"privileged":     {target: "https://example.com/auth?redirect_uri=http%3A%2F%2Flocalhost%3A80"},
+		"not a redirect": {target: "not a url"},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			port, ok := CallbackPort(tc.target)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.port, port)
+		})
+	}
+}
+
+type browserOpener struct {
+	page chan string
+}
+
+// Open simulates a browser that is immediately redirected to the callback
+// with a code.
+func (b *browserOpener) Open(ctx context.Context, target string) error {
+	parsed, err := url.Parse(target)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		response, err := http.Get(parsed.Query().Get("redirect_uri") + "?code=abc")
+		if err != nil {
+			b.page <- err.Error()
+			return
+		}
+		defer response.Body.Close()
+
+		body, _ := io.ReadAll(response.Body)
+		b.page <- string(body)
+	}()
+
+	return nil
+}
+
+func freePort(t *testing.T) int {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func TestRelay(t *testing.T) {
+	registry := handler.NewRegistry()
+	browser := &browserOpener{page: make(chan string, 1)}
+	require.NoError(t, Register(registry, browser))
+
+	// The callback server started by a CLI on the remote machine.
+	remote, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	remotePort := remote.Addr().(*net.TCPAddr).Port
+	go http.Serve(remote, http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(rw, "logged in with %s", r.URL.Query().Get("code"))
+	}))
+	defer remote.Close()
+
+	hostPort := freePort(t)
+	target := "https://example.com/auth?redirect_uri=" + url.QueryEscape(fmt.Sprintf("http://127.0.0.1:%d/callback", hostPort))
+
+	// The host only listens on the port the target redirects to.
+	for _, port := range []int{hostPort + 1, 22} {
+		_, err := registry.Dispatch(context.Background(), &handler.Request{
+			Name:      "relay-open",
+			Arguments: []string{target, fmt.Sprint(port)},
+		})
+		require.Equal(t, handler.CodeInvalid, handler.CodeOf(err))
+	}
+	_, err = registry.Dispatch(context.Background(), &handler.Request{
+		Name:      "relay-open",
+		Arguments: []string{"http://127.0.0.1:22/", "22"},
+	})
+	require.Equal(t, handler.CodeInvalid, handler.CodeOf(err))
+
+	result, err := registry.Dispatch(context.Background(), &handler.Request{
+		Name:      "relay-open",
+		Arguments: []string{target, fmt.Sprint(hostPort)},
+	})
+	require.NoError(t, err)
+
+	var callback Request
+	require.NoError(t, json.Unmarshal(result, &callback))
+	require.Equal(t, http.MethodGet, callback.Method)
+	require.Equal(t, "/callback?code=abc", callback.RequestURI)
+
+	response, err := Replay(context.Background(), remotePort, &callback)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	data, err := json.Marshal(response)
+	require.NoError(t, err)
+
+	_, err = registry.Dispatch(context.Background(), &handler.Request{
+		Name:      "relay-response",
+		Arguments: []string{callback.ID, string(data)},
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, "logged in with abc", <-browser.page)
+
+	_, err = registry.Dispatch(context.Background(), &handler.Request{
+		Name:      "relay-response",
+		Arguments: []string{"missing", string(data)},
+	})
+	require.Equal(t, handler.CodeNotFound, handler.CodeOf(err))
+}
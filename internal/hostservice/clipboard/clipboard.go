@@ -1,9 +1,11 @@
 package clipboard
 
+import "context"
+
 // Clipboard interacts with the system clipboard.
 type Clipboard interface {
 	// Copy a string to the clipboard.
-	Copy(string) error
+	Copy(ctx context.Context, input string) error
 	// Retrieve the current contents of the clipboard.
-	Paste() ([]byte, error)
+	Paste(ctx context.Context) ([]byte, error)
 }
@@ -1,8 +1,10 @@
 package clipboard
 
 import (
+	"context"
 	"fmt"
 	"os/exec"
+	"strings"
 )
 
 type command struct {
@@ -15,46 +17,38 @@ type commandClipboard struct {
 	paste *command
 }
 
-func (m *commandClipboard) Copy(input string) error {
-	cmd := exec.Command(m.copy.name, m.copy.argv...)
-	stdin, err := cmd.StdinPipe()
+// Copy runs the copy command with input on stdin. The command is killed if
+// ctx is done before it exits, e.g. when xclip hangs without an X server.
+func (m *commandClipboard) Copy(ctx context.Context, input string) error {
+	cmd := exec.CommandContext(ctx, m.copy.name, m.copy.argv...)
+	cmd.Stdin = strings.NewReader(input)
 
-	if err != nil {
-		return fmt.Errorf("could not create %v stdin: %w", m.copy.name, err)
-	}
-
-	_, err = stdin.Write([]byte(input))
-
-	if err != nil {
-		return fmt.Errorf("could not create write to %v: %w", m.copy.name, err)
-	}
-
-	err = stdin.Close()
-
-	if err != nil {
-		return fmt.Errorf("could not create close %v stdin: %w", m.copy.name, err)
-	}
-
-	err = cmd.Run()
-
-	if err != nil {
-		return fmt.Errorf("could not run %v command: %w", m.copy.name, err)
+	if err := cmd.Run(); err != nil {
+		return commandError(ctx, m.copy.name, err)
 	}
 
 	return nil
 }
 
-func (m *commandClipboard) Paste() ([]byte, error) {
-	cmd := exec.Command(m.paste.name, m.paste.argv...)
+// Paste runs the paste command and returns its output. The command is killed
+// if ctx is done before it exits.
+func (m *commandClipboard) Paste(ctx context.Context) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, m.paste.name, m.paste.argv...)
 
 	contents, err := cmd.Output()
 	if err != nil {
-		return nil, fmt.Errorf("could not run %v: %w", m.paste.name, err)
+		return nil, commandError(ctx, m.paste.name, err)
 	}
 
-	if err != nil {
-		return nil, fmt.Errorf("could not read stdout for %v: %w", m.paste.name, err)
+	return contents, nil
+}
+
+// commandError reports ctx's error rather than the "signal: killed" returned
+// by commands killed when ctx is done.
+func commandError(ctx context.Context, name string, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return fmt.Errorf("could not run %v command: %w", name, ctxErr)
 	}
 
-	return contents, nil
+	return fmt.Errorf("could not run %v command: %w", name, err)
 }
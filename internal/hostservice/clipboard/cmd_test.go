@@ -0,0 +1,41 @@
+package clipboard
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandClipboard_Timeout(t *testing.T) {
+	hung := &commandClipboard{
+		copy:  &command{"sleep", []string{"10"}},
+		paste: &command{"sleep", []string{"10"}},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := hung.Copy(ctx, "hello")
+	require.True(t, errors.Is(err, context.DeadlineExceeded), "unexpected error: %v", err)
+
+	_, err = hung.Paste(ctx)
+	require.True(t, errors.Is(err, context.DeadlineExceeded), "unexpected error: %v", err)
+	require.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestCommandClipboard(t *testing.T) {
+	echo := &commandClipboard{
+		copy:  &command{"cat", []string{}},
+		paste: &command{"echo", []string{"-n", "hello"}},
+	}
+
+	require.NoError(t, echo.Copy(context.Background(), "hello"))
+
+	contents, err := echo.Paste(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(contents))
+}
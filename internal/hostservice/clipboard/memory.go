@@ -0,0 +1,82 @@
+package clipboard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Memory is a clipboard held by the server process, for hosts without a
+// display. Its contents are optionally persisted to a file so they survive
+// restarts. It is safe for concurrent use.
+type Memory struct {
+	mu      sync.Mutex
+	content string
+	path    string
+}
+
+// NewMemory returns an empty in-memory clipboard.
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+// NewPersistentMemory returns an in-memory clipboard that saves its contents
+// to path, starting with the contents saved by a previous server.
+func NewPersistentMemory(path string) (*Memory, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("could not read clipboard from %s: %w", path, err)
+	}
+
+	return &Memory{content: string(contents), path: path}, nil
+}
+
+func (m *Memory) Copy(ctx context.Context, input string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.path != "" {
+		if err := m.save(input); err != nil {
+			return err
+		}
+	}
+
+	m.content = input
+	return nil
+}
+
+func (m *Memory) Paste(ctx context.Context) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return []byte(m.content), nil
+}
+
+// save atomically replaces the persisted contents, only allowing access by the
+// current user.
+func (m *Memory) save(input string) error {
+	file, err := os.CreateTemp(filepath.Dir(m.path), ".clipboard-")
+	if err != nil {
+		return fmt.Errorf("could not save clipboard: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.WriteString(input); err != nil {
+		file.Close()
+		return fmt.Errorf("could not save clipboard: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("could not save clipboard: %w", err)
+	}
+
+	if err := os.Rename(file.Name(), m.path); err != nil {
+		return fmt.Errorf("could not save clipboard: %w", err)
+	}
+
+	return nil
+}
+
+var _ Clipboard = (*Memory)(nil)
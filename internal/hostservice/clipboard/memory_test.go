@@ -0,0 +1,41 @@
+package clipboard
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemory(t *testing.T) {
+	memory := NewMemory()
+
+	contents, err := memory.Paste(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, contents)
+
+	require.NoError(t, memory.Copy(context.Background(), "hello"))
+	contents, err = memory.Paste(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(contents))
+}
+
+func TestPersistentMemory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clipboard")
+
+	memory, err := NewPersistentMemory(path)
+	require.NoError(t, err)
+	require.NoError(t, memory.Copy(context.Background(), "hello"))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	restored, err := NewPersistentMemory(path)
+	require.NoError(t, err)
+	contents, err := restored.Paste(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(contents))
+}
@@ -1,17 +1,17 @@
 package clipboard
 
-type TestClipboard struct {
-	Buffer string
-}
+import "context"
 
-func (tc *TestClipboard) Copy(input string) error {
-	tc.Buffer = input
-
-	return nil
+// TestClipboard is an in-memory clipboard for tests. It is safe for concurrent
+// use.
+type TestClipboard struct {
+	Memory
 }
 
-func (tc *TestClipboard) Paste() ([]byte, error) {
-	return []byte(tc.Buffer), nil
+// Contents returns the current contents of the clipboard.
+func (tc *TestClipboard) Contents() string {
+	contents, _ := tc.Paste(context.Background())
+	return string(contents)
 }
 
 func NewTestClipboard() *TestClipboard {
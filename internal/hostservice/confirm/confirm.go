@@ -0,0 +1,32 @@
+package confirm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+)
+
+// Func asks the user on the host system to allow or deny an action described
+// by message, returning true if it was allowed.
+type Func func(ctx context.Context, message string) (bool, error)
+
+// Confirm shows a dialog on the host system using a platform-specific command.
+// Closing the dialog or choosing "Deny" denies the action.
+func Confirm(ctx context.Context, message string) (bool, error) {
+	name, argv := confirmCommand(message)
+	cmd := exec.CommandContext(ctx, name, argv...)
+
+	err := cmd.Run()
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && ctx.Err() == nil {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("could not run confirm command: %w", err)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,16 @@
+//go:build darwin
+// +build darwin
+
+package confirm
+
+// confirmCommand returns an AppleScript dialog which exits with a non-zero
+// status when denied. The message is passed as an argument so it is never
+// interpreted as AppleScript.
+func confirmCommand(message string) (string, []string) {
+	return "osascript", []string{
+		"-e", "on run argv",
+		"-e", `display dialog (item 1 of argv) with title "rdm" buttons {"Deny", "Allow"} default button "Deny" cancel button "Deny" with icon caution`,
+		"-e", "end run",
+		message,
+	}
+}
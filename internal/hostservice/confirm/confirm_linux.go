@@ -0,0 +1,17 @@
+//go:build linux
+// +build linux
+
+package confirm
+
+// confirmCommand returns a zenity dialog which exits with a non-zero status
+// when denied.
+func confirmCommand(message string) (string, []string) {
+	return "zenity", []string{
+		"--question",
+		"--title", "rdm",
+		"--no-markup",
+		"--ok-label", "Allow",
+		"--cancel-label", "Deny",
+		"--text", message,
+	}
+}
@@ -0,0 +1,108 @@
+package editor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// savePollInterval is how often the file is checked for changes when waiting
+// for a save rather than for the editor to exit.
+const savePollInterval = 500 * time.Millisecond
+
+// Editor opens files in a GUI editor on the host system.
+type Editor struct {
+	command     []string
+	waitForSave bool
+}
+
+// New returns an Editor running command with the file path appended. When
+// command is empty a platform-specific default is used, falling back to
+// $VISUAL. If waitForSave is true, Edit returns after the file is first saved
+// instead of waiting for the editor to exit, which is useful for editors that
+// can't block until a file is closed.
+func New(command []string, waitForSave bool) *Editor {
+	if len(command) == 0 {
+		command = defaultCommand
+	}
+
+	if len(command) == 0 {
+		command = strings.Fields(os.Getenv("VISUAL"))
+	}
+
+	return &Editor{
+		command:     command,
+		waitForSave: waitForSave,
+	}
+}
+
+// Edit opens the file at path and blocks until the editor exits, or until the
+// file is saved when waiting for saves. The returned channel is closed once the
+// editor exits, which may be after Edit returns, so the file must be kept
+// until then.
+func (e *Editor) Edit(ctx context.Context, path string) (<-chan struct{}, error) {
+	if len(e.command) == 0 {
+		return nil, errors.New("no editor is configured on the host")
+	}
+
+	argv := append(append([]string{}, e.command[1:]...), path)
+	// The editor isn't tied to ctx since it outlives the request when waiting
+	// for a save, and killing it would lose unsaved changes.
+	cmd := exec.Command(e.command[0], argv...)
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("could not start %v: %w", e.command[0], err)
+	}
+
+	exited := make(chan error, 1)
+	done := make(chan struct{})
+	go func() {
+		exited <- cmd.Wait()
+		close(done)
+	}()
+
+	if !e.waitForSave {
+		select {
+		case <-ctx.Done():
+			return done, ctx.Err()
+		case err := <-exited:
+			if err != nil {
+				return done, fmt.Errorf("could not run %v: %w", e.command[0], err)
+			}
+			return done, nil
+		}
+	}
+
+	return done, waitForSave(ctx, path, exited)
+}
+
+// waitForSave returns once the modification time of the file changes or the
+// editor exits.
+func waitForSave(ctx context.Context, path string, exited <-chan error) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	modified := info.ModTime()
+
+	ticker := time.NewTicker(savePollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-exited:
+			return err
+		case <-ticker.C:
+			info, err := os.Stat(path)
+			if err == nil && !info.ModTime().Equal(modified) {
+				return nil
+			}
+		}
+	}
+}
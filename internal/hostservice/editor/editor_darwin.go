@@ -0,0 +1,8 @@
+//go:build darwin
+// +build darwin
+
+package editor
+
+// defaultCommand opens the file in a new instance of the default text editor
+// and waits for it to quit.
+var defaultCommand = []string{"open", "-W", "-n", "-t"}
@@ -0,0 +1,8 @@
+//go:build linux
+// +build linux
+
+package editor
+
+// defaultCommand is empty since xdg-open can't wait for the editor to exit,
+// so $VISUAL is used instead.
+var defaultCommand []string
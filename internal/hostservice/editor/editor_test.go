@@ -0,0 +1,38 @@
+package editor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEditor_WaitForSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(path, nil, 0600))
+
+	// Make sure the save changes the modification time.
+	past := time.Now().Add(-time.Minute)
+	require.NoError(t, os.Chtimes(path, past, past))
+
+	editor := New([]string{"sh", "-c", `echo saved >> "$0"; sleep 1; echo closed >> "$0"`}, true)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	exited, err := editor.Edit(ctx, path)
+	require.NoError(t, err)
+
+	// The editor keeps running after the request is done.
+	cancel()
+	select {
+	case <-exited:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the editor to exit")
+	}
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "saved\nclosed\n", string(content))
+}
@@ -1,6 +1,7 @@
 package open
 
 import (
+	"context"
 	"fmt"
 	"os/exec"
 )
@@ -8,16 +9,20 @@ import (
 // Opener causes the side effect of opening a referenced target in an
 // appropriate way on the host system, probably by launching a browser.
 type Opener interface {
-	Open(target string) error
+	Open(ctx context.Context, target string) error
 }
 
 // Open opens the target on the host system using a platform-specific command.
-func Open(target string) error {
-	cmd := exec.Command(openCommand, target)
+// The command is killed if ctx is done before it exits.
+func Open(ctx context.Context, target string) error {
+	cmd := exec.CommandContext(ctx, openCommand, target)
 
 	err := cmd.Run()
 
 	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return fmt.Errorf("could not run open command: %w", ctxErr)
+		}
 		return fmt.Errorf("could not run open command: %w", err)
 	}
 
@@ -1,6 +1,8 @@
 package hostservice
 
 import (
+	"context"
+
 	"github.com/blakewilliams/remote-development-manager/internal/hostservice/clipboard"
 	"github.com/blakewilliams/remote-development-manager/internal/hostservice/open"
 )
@@ -12,31 +14,84 @@ type Runner interface {
 }
 
 // HostService is a default implementation of Runner which exposes
-// system capabilities.
+// system capabilities. It is safe for concurrent use: the server handles
+// requests concurrently, so access to each resource is serialized.
 type HostService struct {
-	clipboard clipboard.Clipboard
+	// clipboardLock is held while copying or pasting, since clipboard
+	// commands like xclip can interleave when run concurrently.
+	clipboardLock lock
+	clipboard     clipboard.Clipboard
+	// openLock is held while opening a target so browsers receive them in
+	// order.
+	openLock lock
+	open     func(ctx context.Context, target string) error
 }
 
 // New returns a HostService.
 func New() *HostService {
+	return NewWithClipboard(clipboard.New())
+}
+
+// NewWithClipboard returns a HostService using the given clipboard instead of
+// the system clipboard.
+func NewWithClipboard(clipboard clipboard.Clipboard) *HostService {
 	return &HostService{
-		clipboard: clipboard.New(),
+		clipboardLock: newLock(),
+		clipboard:     clipboard,
+		openLock:      newLock(),
+		open:          open.Open,
 	}
 }
 
 // Copy a string to the host system's clipboard.
-func (svc *HostService) Copy(s string) error {
-	return svc.clipboard.Copy(s)
+func (svc *HostService) Copy(ctx context.Context, s string) error {
+	if err := svc.clipboardLock.acquire(ctx); err != nil {
+		return err
+	}
+	defer svc.clipboardLock.release()
+
+	return svc.clipboard.Copy(ctx, s)
 }
 
 // Paste a string from the host system's clipboard.
-func (svc *HostService) Paste() ([]byte, error) {
-	return svc.clipboard.Paste()
+func (svc *HostService) Paste(ctx context.Context) ([]byte, error) {
+	if err := svc.clipboardLock.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer svc.clipboardLock.release()
+
+	return svc.clipboard.Paste(ctx)
 }
 
 // Open the target on the host system, most likely by opening a browser.
-func (svc *HostService) Open(target string) error {
-	return open.Open(target)
+func (svc *HostService) Open(ctx context.Context, target string) error {
+	if err := svc.openLock.acquire(ctx); err != nil {
+		return err
+	}
+	defer svc.openLock.release()
+
+	return svc.open(ctx, target)
+}
+
+// lock is a mutex that callers stop waiting for once their context is done,
+// so a request queued behind a hung command still times out.
+type lock chan struct{}
+
+func newLock() lock {
+	return make(lock, 1)
+}
+
+func (l lock) acquire(ctx context.Context) error {
+	select {
+	case l <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l lock) release() {
+	<-l
 }
 
 // Compile-time assertion that HostService implements Runner.
@@ -0,0 +1,116 @@
+package hostservice
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// exclusiveClipboard records the most calls that were ever in progress at
+// once.
+type exclusiveClipboard struct {
+	active  int32
+	maxSeen int32
+	buffer  string
+}
+
+func (c *exclusiveClipboard) enter() func() {
+	active := atomic.AddInt32(&c.active, 1)
+	for {
+		seen := atomic.LoadInt32(&c.maxSeen)
+		if active <= seen || atomic.CompareAndSwapInt32(&c.maxSeen, seen, active) {
+			break
+		}
+	}
+
+	// Give other calls a chance to overlap.
+	time.Sleep(time.Millisecond)
+
+	return func() { atomic.AddInt32(&c.active, -1) }
+}
+
+func (c *exclusiveClipboard) Copy(ctx context.Context, s string) error {
+	defer c.enter()()
+	c.buffer = s
+	return nil
+}
+
+func (c *exclusiveClipboard) Paste(ctx context.Context) ([]byte, error) {
+	defer c.enter()()
+	return []byte(c.buffer), nil
+}
+
+func TestHostService_Concurrent(t *testing.T) {
+	clipboard := &exclusiveClipboard{}
+	svc := NewWithClipboard(clipboard)
+
+	var opened int32
+	svc.open = func(ctx context.Context, target string) error {
+		atomic.AddInt32(&opened, 1)
+		return nil
+	}
+
+	// Goroutines report errors rather than failing the test themselves.
+	errs := make(chan error, 60)
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			errs <- svc.Copy(context.Background(), "hello")
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := svc.Paste(context.Background())
+			errs <- err
+		}()
+		go func() {
+			defer wg.Done()
+			errs <- svc.Open(context.Background(), "https://example.com")
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, int32(1), clipboard.maxSeen)
+	require.Equal(t, int32(20), opened)
+}
+
+// hungClipboard blocks every call until its context is done.
+type hungClipboard struct{}
+
+func (hungClipboard) Copy(ctx context.Context, s string) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (hungClipboard) Paste(ctx context.Context) ([]byte, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestHostService_QueuedTimeout(t *testing.T) {
+	svc := NewWithClipboard(hungClipboard{})
+
+	hung, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go svc.Copy(hung, "hello")
+
+	// Wait for the hung copy to hold the clipboard.
+	require.Eventually(t, func() bool { return len(svc.clipboardLock) == 1 }, time.Second, time.Millisecond)
+
+	ctx, cancelQueued := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelQueued()
+
+	_, err := svc.Paste(ctx)
+	require.True(t, errors.Is(err, context.DeadlineExceeded), "unexpected error: %v", err)
+}
@@ -0,0 +1,20 @@
+//go:build darwin
+// +build darwin
+
+package logging
+
+import (
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// createdAt returns when the file at path was created.
+func createdAt(path string) (time.Time, bool) {
+	var stat unix.Stat_t
+	if err := unix.Stat(path, &stat); err != nil {
+		return time.Time{}, false
+	}
+
+	return time.Unix(stat.Btim.Unix()), true
+}
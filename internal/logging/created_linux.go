@@ -0,0 +1,25 @@
+//go:build linux
+// +build linux
+
+package logging
+
+import (
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// createdAt returns when the file at path was created, which not every
+// filesystem records.
+func createdAt(path string) (time.Time, bool) {
+	var stat unix.Statx_t
+	if err := unix.Statx(unix.AT_FDCWD, path, 0, unix.STATX_BTIME, &stat); err != nil {
+		return time.Time{}, false
+	}
+
+	if stat.Mask&unix.STATX_BTIME == 0 {
+		return time.Time{}, false
+	}
+
+	return time.Unix(stat.Btime.Sec, int64(stat.Btime.Nsec)), true
+}
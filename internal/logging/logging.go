@@ -0,0 +1,265 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level is the severity of a log entry.
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = map[Level]string{
+	LevelDebug: "debug",
+	LevelInfo:  "info",
+	LevelWarn:  "warn",
+	LevelError: "error",
+}
+
+func (l Level) String() string {
+	return levelNames[l]
+}
+
+// ParseLevel returns the level with the given name, e.g. "debug".
+func ParseLevel(name string) (Level, error) {
+	for level, levelName := range levelNames {
+		if strings.EqualFold(name, levelName) {
+			return level, nil
+		}
+	}
+
+	return LevelInfo, fmt.Errorf("unknown log level %q, expected debug, info, warn or error", name)
+}
+
+// Format is the encoding of log entries.
+type Format string
+
+const (
+	FormatLogfmt Format = "logfmt"
+	FormatJSON   Format = "json"
+)
+
+// ParseFormat returns the format with the given name.
+func ParseFormat(name string) (Format, error) {
+	switch Format(strings.ToLower(name)) {
+	case FormatLogfmt:
+		return FormatLogfmt, nil
+	case FormatJSON:
+		return FormatJSON, nil
+	default:
+		return FormatLogfmt, fmt.Errorf("unknown log format %q, expected logfmt or json", name)
+	}
+}
+
+// Logger writes leveled entries made up of a message and key-value pairs. It
+// is safe for concurrent use.
+type Logger struct {
+	mu     *sync.Mutex
+	out    io.Writer
+	level  Level
+	format Format
+	fields []interface{}
+}
+
+// New returns a logger writing entries at level and above to out.
+func New(out io.Writer, level Level, format Format) *Logger {
+	return &Logger{
+		mu:     &sync.Mutex{},
+		out:    out,
+		level:  level,
+		format: format,
+	}
+}
+
+// Discard returns a logger that drops every entry.
+func Discard() *Logger {
+	return New(io.Discard, LevelError, FormatLogfmt)
+}
+
+// With returns a logger that adds the key-value pairs to every entry.
+func (l *Logger) With(keyvals ...interface{}) *Logger {
+	fields := make([]interface{}, 0, len(l.fields)+len(keyvals))
+	fields = append(fields, l.fields...)
+	fields = append(fields, keyvals...)
+
+	return &Logger{
+		mu:     l.mu,
+		out:    l.out,
+		level:  l.level,
+		format: l.format,
+		fields: fields,
+	}
+}
+
+func (l *Logger) Debug(msg string, keyvals ...interface{}) {
+	l.log(LevelDebug, msg, keyvals)
+}
+
+func (l *Logger) Info(msg string, keyvals ...interface{}) {
+	l.log(LevelInfo, msg, keyvals)
+}
+
+func (l *Logger) Warn(msg string, keyvals ...interface{}) {
+	l.log(LevelWarn, msg, keyvals)
+}
+
+func (l *Logger) Error(msg string, keyvals ...interface{}) {
+	l.log(LevelError, msg, keyvals)
+}
+
+// StdLogger returns a standard library logger that writes each line as an
+// entry at level, for packages like net/http that expect one.
+func (l *Logger) StdLogger(level Level) *log.Logger {
+	return log.New(&lineWriter{logger: l, level: level}, "", 0)
+}
+
+func (l *Logger) log(level Level, msg string, keyvals []interface{}) {
+	if level < l.level {
+		return
+	}
+
+	keyvals = append(append([]interface{}{}, l.fields...), keyvals...)
+	// A trailing key without a value is kept rather than silently dropped.
+	if len(keyvals)%2 != 0 {
+		keyvals = append(keyvals, nil)
+	}
+
+	var entry []byte
+	if l.format == FormatJSON {
+		entry = encodeJSON(time.Now(), level, msg, keyvals)
+	} else {
+		entry = encodeLogfmt(time.Now(), level, msg, keyvals)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.out.Write(entry)
+}
+
+func encodeJSON(now time.Time, level Level, msg string, keyvals []interface{}) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	writeJSONField(&buf, "time", now.UTC().Format(time.RFC3339Nano))
+	buf.WriteByte(',')
+	writeJSONField(&buf, "level", level.String())
+	buf.WriteByte(',')
+	writeJSONField(&buf, "msg", msg)
+
+	for i := 0; i < len(keyvals); i += 2 {
+		buf.WriteByte(',')
+		writeJSONField(&buf, fmt.Sprint(keyvals[i]), jsonValue(keyvals[i+1]))
+	}
+
+	buf.WriteString("}\n")
+	return buf.Bytes()
+}
+
+func writeJSONField(buf *bytes.Buffer, key string, value interface{}) {
+	encodedKey, _ := json.Marshal(key)
+	encodedValue, err := json.Marshal(value)
+	if err != nil {
+		encodedValue, _ = json.Marshal(fmt.Sprint(value))
+	}
+
+	buf.Write(encodedKey)
+	buf.WriteByte(':')
+	buf.Write(encodedValue)
+}
+
+// jsonValue converts errors and stringers, which usually encode as empty
+// objects, to strings.
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return v
+	}
+}
+
+func encodeLogfmt(now time.Time, level Level, msg string, keyvals []interface{}) []byte {
+	var buf bytes.Buffer
+	writeLogfmtField(&buf, "time", now.UTC().Format(time.RFC3339Nano))
+	buf.WriteByte(' ')
+	writeLogfmtField(&buf, "level", level.String())
+	buf.WriteByte(' ')
+	writeLogfmtField(&buf, "msg", msg)
+
+	for i := 0; i < len(keyvals); i += 2 {
+		buf.WriteByte(' ')
+		writeLogfmtField(&buf, fmt.Sprint(keyvals[i]), logfmtValue(keyvals[i+1]))
+	}
+
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
+
+func writeLogfmtField(buf *bytes.Buffer, key string, value string) {
+	buf.WriteString(strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' {
+			return '_'
+		}
+		return r
+	}, key))
+	buf.WriteByte('=')
+
+	if value == "" || strings.ContainsAny(value, " =\"\\") || strings.IndexFunc(value, func(r rune) bool { return r < ' ' }) >= 0 {
+		encoded, _ := json.Marshal(value)
+		buf.Write(encoded)
+		return
+	}
+
+	buf.WriteString(value)
+}
+
+func logfmtValue(value interface{}) string {
+	if value == nil {
+		return "nil"
+	}
+
+	return fmt.Sprint(value)
+}
+
+// lineWriter logs each write as a single entry.
+type lineWriter struct {
+	logger *Logger
+	level  Level
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.logger.log(w.level, strings.TrimRight(string(p), "\n"), nil)
+	return len(p), nil
+}
+
+type contextKey struct{}
+
+// NewContext returns a context carrying logger, typically one with request
+// scoped fields.
+func NewContext(ctx context.Context, logger *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the logger stored in ctx by NewContext, or fallback if
+// there is none.
+func FromContext(ctx context.Context, fallback *Logger) *Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*Logger); ok {
+		return logger
+	}
+
+	return fallback
+}
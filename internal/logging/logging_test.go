@@ -0,0 +1,72 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogger_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, LevelInfo, FormatJSON).With("request_id", "abc123")
+
+	logger.Debug("hidden")
+	logger.Warn("command failed", "command", "open", "error", errors.New("not found"), "bytes", 12)
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	require.Equal(t, "warn", entry["level"])
+	require.Equal(t, "command failed", entry["msg"])
+	require.Equal(t, "abc123", entry["request_id"])
+	require.Equal(t, "open", entry["command"])
+	require.Equal(t, "not found", entry["error"])
+	require.Equal(t, float64(12), entry["bytes"])
+	require.Contains(t, entry, "time")
+}
+
+func TestLogger_Logfmt(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, LevelDebug, FormatLogfmt)
+
+	logger.With("request_id", "abc123").Debug("running command", "command", "copy", "client", `blake "laptop"`, "dangling")
+
+	line := buf.String()
+	require.Contains(t, line, " level=debug msg=\"running command\" request_id=abc123 command=copy client=\"blake \\\"laptop\\\"\" dangling=nil\n")
+}
+
+func TestLogger_StdLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, LevelInfo, FormatLogfmt)
+
+	logger.StdLogger(LevelError).Printf("http: accept error: %s", "oops")
+
+	require.Contains(t, buf.String(), `level=error msg="http: accept error: oops"`)
+}
+
+func TestParse(t *testing.T) {
+	level, err := ParseLevel("WARN")
+	require.NoError(t, err)
+	require.Equal(t, LevelWarn, level)
+
+	_, err = ParseLevel("verbose")
+	require.Error(t, err)
+
+	format, err := ParseFormat("json")
+	require.NoError(t, err)
+	require.Equal(t, FormatJSON, format)
+
+	_, err = ParseFormat("xml")
+	require.Error(t, err)
+}
+
+func TestContext(t *testing.T) {
+	fallback := Discard()
+	require.Same(t, fallback, FromContext(context.Background(), fallback))
+
+	logger := fallback.With("request_id", "abc123")
+	require.Same(t, logger, FromContext(NewContext(context.Background(), logger), fallback))
+}
@@ -0,0 +1,177 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Rotation configures when a RotatingFile starts a new file. Zero values
+// disable the corresponding limit.
+type Rotation struct {
+	// MaxSize is the size in bytes a file may grow to before it's rotated.
+	MaxSize int64
+	// MaxAge is how long a file is written to before it's rotated.
+	MaxAge time.Duration
+	// MaxFiles is the number of rotated files kept alongside the active one,
+	// named path.1 (the most recent) through path.MaxFiles.
+	MaxFiles int
+}
+
+// RotatingFile is a log file that is rotated by size and age. It is safe for
+// concurrent use.
+type RotatingFile struct {
+	path     string
+	rotation Rotation
+	now      func() time.Time
+
+	mu      sync.Mutex
+	file    *os.File
+	size    int64
+	started time.Time
+}
+
+// OpenRotatingFile opens the log file at path for appending, creating it if
+// needed with permissions only allowing access by the current user.
+func OpenRotatingFile(path string, rotation Rotation) (*RotatingFile, error) {
+	f := &RotatingFile{path: path, rotation: rotation, now: time.Now}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Path returns the location of the active log file.
+func (f *RotatingFile) Path() string {
+	return f.path
+}
+
+// Write appends p to the active file, rotating it first if p would exceed the
+// maximum size or the file is older than the maximum age.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.shouldRotate(int64(len(p))) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+
+	return n, err
+}
+
+// Close closes the active file.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.file.Close()
+}
+
+// open opens the file at path. The age of an existing file is measured from
+// its creation, or from now when the filesystem doesn't record it. The last
+// modification can't be used since a file that's written to regularly would
+// never age.
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("could not open log file %s: %w", f.path, err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("could not open log file %s: %w", f.path, err)
+	}
+
+	// Files created by older versions were world writable.
+	if info.Mode().Perm() != 0600 {
+		file.Chmod(0600)
+	}
+
+	f.file = file
+	f.size = info.Size()
+	f.started = f.now()
+	if created, ok := createdAt(f.path); ok && f.size > 0 {
+		f.started = created
+	}
+
+	return nil
+}
+
+func (f *RotatingFile) shouldRotate(pending int64) bool {
+	if f.size == 0 {
+		return false
+	}
+
+	if f.rotation.MaxSize > 0 && f.size+pending > f.rotation.MaxSize {
+		return true
+	}
+
+	return f.rotation.MaxAge > 0 && f.now().Sub(f.started) >= f.rotation.MaxAge
+}
+
+// rotate renames the active file to path.1, shifting older files up and
+// removing those beyond MaxFiles, then opens a new active file. When rotating
+// fails the active file is reopened so later writes aren't lost.
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("could not rotate log file %s: %w", f.path, err)
+	}
+
+	if err := f.shift(); err != nil {
+		if openErr := f.open(); openErr != nil {
+			return openErr
+		}
+		return fmt.Errorf("could not rotate log file %s: %w", f.path, err)
+	}
+
+	return f.open()
+}
+
+// shift moves each file to the next rotated path.
+func (f *RotatingFile) shift() error {
+	// Without retained files this removes the active file itself.
+	if err := removeIfExists(f.rotatedPath(f.rotation.MaxFiles)); err != nil {
+		return err
+	}
+
+	for i := f.rotation.MaxFiles - 1; i >= 0; i-- {
+		err := os.Rename(f.rotatedPath(i), f.rotatedPath(i+1))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rotatedPath returns the path of the nth rotated file, where 0 is the active
+// file.
+func (f *RotatingFile) rotatedPath(n int) string {
+	if n <= 0 {
+		return f.path
+	}
+
+	return fmt.Sprintf("%s.%d", f.path, n)
+}
+
+func removeIfExists(path string) error {
+	err := os.Remove(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,126 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotatingFile_Size(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rdm.log")
+
+	file, err := OpenRotatingFile(path, Rotation{MaxSize: 10, MaxFiles: 2})
+	require.NoError(t, err)
+	defer file.Close()
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		_, err := file.Write([]byte(line))
+		require.NoError(t, err)
+	}
+
+	requireContents(t, path, "fourth\n")
+	requireContents(t, path+".1", "third\n")
+	requireContents(t, path+".2", "second\n")
+	require.NoFileExists(t, path+".3")
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestRotatingFile_Age(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rdm.log")
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	file, err := OpenRotatingFile(path, Rotation{MaxAge: time.Hour, MaxFiles: 1})
+	require.NoError(t, err)
+	defer file.Close()
+	file.now = func() time.Time { return now }
+	file.started = now
+
+	_, err = file.Write([]byte("first\n"))
+	require.NoError(t, err)
+
+	now = now.Add(30 * time.Minute)
+	_, err = file.Write([]byte("second\n"))
+	require.NoError(t, err)
+	require.NoFileExists(t, path+".1")
+
+	now = now.Add(30 * time.Minute)
+	_, err = file.Write([]byte("third\n"))
+	require.NoError(t, err)
+
+	requireContents(t, path, "third\n")
+	requireContents(t, path+".1", "first\nsecond\n")
+}
+
+func TestRotatingFile_NoRetainedFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rdm.log")
+
+	file, err := OpenRotatingFile(path, Rotation{MaxSize: 10})
+	require.NoError(t, err)
+	defer file.Close()
+
+	for _, line := range []string{"first\n", "second\n"} {
+		_, err := file.Write([]byte(line))
+		require.NoError(t, err)
+	}
+
+	requireContents(t, path, "second\n")
+	require.NoFileExists(t, path+".1")
+}
+
+func TestRotatingFile_AgeOfExistingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rdm.log")
+	require.NoError(t, os.WriteFile(path, []byte("first\n"), 0600))
+
+	// A file last written to long ago was still created just now.
+	past := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(path, past, past))
+
+	file, err := OpenRotatingFile(path, Rotation{MaxAge: time.Hour, MaxFiles: 1})
+	require.NoError(t, err)
+	defer file.Close()
+
+	_, err = file.Write([]byte("second\n"))
+	require.NoError(t, err)
+
+	requireContents(t, path, "first\nsecond\n")
+	require.NoFileExists(t, path+".1")
+}
+
+func TestRotatingFile_FailedRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rdm.log")
+
+	// A directory in place of the rotated file can't be removed.
+	require.NoError(t, os.MkdirAll(filepath.Join(path+".1", "blocked"), 0700))
+
+	file, err := OpenRotatingFile(path, Rotation{MaxSize: 10, MaxFiles: 1})
+	require.NoError(t, err)
+	defer file.Close()
+
+	_, err = file.Write([]byte("first\n"))
+	require.NoError(t, err)
+
+	_, err = file.Write([]byte("second\n"))
+	require.Error(t, err)
+
+	// The active file is still written to once rotating works again.
+	require.NoError(t, os.RemoveAll(path+".1"))
+	_, err = file.Write([]byte("third\n"))
+	require.NoError(t, err)
+
+	requireContents(t, path, "third\n")
+	requireContents(t, path+".1", "first\n")
+}
+
+func requireContents(t *testing.T, path string, expected string) {
+	t.Helper()
+
+	contents, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, expected, string(contents))
+}
@@ -0,0 +1,104 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Limit is a token bucket allowing Burst requests at once, with a request
+// becoming available again every Cooldown.
+type Limit struct {
+	Burst    int
+	Cooldown time.Duration
+}
+
+// maxBuckets is the number of buckets kept before full ones are forgotten.
+const maxBuckets = 1024
+
+type key struct {
+	command string
+	session string
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter rate limits commands separately for each session. Commands without
+// a limit are never limited. It is safe for concurrent use.
+type Limiter struct {
+	limits map[string]Limit
+	now    func() time.Time
+
+	mu      sync.Mutex
+	buckets map[key]*bucket
+}
+
+// New returns a limiter applying limits, keyed by command name.
+func New(limits map[string]Limit) *Limiter {
+	return &Limiter{
+		limits:  limits,
+		now:     time.Now,
+		buckets: make(map[key]*bucket),
+	}
+}
+
+// Allow reports whether session may run command now, taking a token if so.
+// Otherwise it returns how long until a token is available.
+func (l *Limiter) Allow(command string, session string) (bool, time.Duration) {
+	limit, ok := l.limits[command]
+	if !ok || limit.Burst <= 0 {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	k := key{command: command, session: session}
+	b, ok := l.buckets[k]
+	if !ok {
+		l.prune(now)
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		l.buckets[k] = b
+	}
+
+	b.refill(limit, now)
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	wait := time.Duration((1 - b.tokens) * float64(limit.Cooldown))
+	return false, wait
+}
+
+func (b *bucket) refill(limit Limit, now time.Time) {
+	if limit.Cooldown <= 0 {
+		b.tokens = float64(limit.Burst)
+	} else if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += float64(elapsed) / float64(limit.Cooldown)
+		if b.tokens > float64(limit.Burst) {
+			b.tokens = float64(limit.Burst)
+		}
+	}
+
+	b.last = now
+}
+
+// prune forgets buckets that have refilled, since they behave the same as new
+// ones, once there are too many.
+func (l *Limiter) prune(now time.Time) {
+	if len(l.buckets) < maxBuckets {
+		return
+	}
+
+	for k, b := range l.buckets {
+		limit := l.limits[k.command]
+		b.refill(limit, now)
+		if b.tokens >= float64(limit.Burst) {
+			delete(l.buckets, k)
+		}
+	}
+}
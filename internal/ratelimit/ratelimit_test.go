@@ -0,0 +1,62 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter(t *testing.T) {
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := New(map[string]Limit{"open": {Burst: 2, Cooldown: time.Second}})
+	limiter.now = func() time.Time { return now }
+
+	allowed, _ := limiter.Allow("open", "foo")
+	require.True(t, allowed)
+	allowed, _ = limiter.Allow("open", "foo")
+	require.True(t, allowed)
+
+	allowed, wait := limiter.Allow("open", "foo")
+	require.False(t, allowed)
+	require.Equal(t, time.Second, wait)
+
+	// Sessions and commands without limits are independent.
+	allowed, _ = limiter.Allow("open", "bar")
+	require.True(t, allowed)
+	allowed, _ = limiter.Allow("copy", "foo")
+	require.True(t, allowed)
+
+	now = now.Add(500 * time.Millisecond)
+	allowed, wait = limiter.Allow("open", "foo")
+	require.False(t, allowed)
+	require.Equal(t, 500*time.Millisecond, wait)
+
+	now = now.Add(500 * time.Millisecond)
+	allowed, _ = limiter.Allow("open", "foo")
+	require.True(t, allowed)
+
+	// Tokens never exceed the burst.
+	now = now.Add(time.Hour)
+	for i := 0; i < 2; i++ {
+		allowed, _ = limiter.Allow("open", "foo")
+		require.True(t, allowed)
+	}
+	allowed, _ = limiter.Allow("open", "foo")
+	require.False(t, allowed)
+}
+
+func TestLimiter_Prune(t *testing.T) {
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := New(map[string]Limit{"open": {Burst: 1, Cooldown: time.Second}})
+	limiter.now = func() time.Time { return now }
+
+	for i := 0; i < maxBuckets; i++ {
+		limiter.Allow("open", string(rune('a'+i)))
+	}
+	require.Len(t, limiter.buckets, maxBuckets)
+
+	now = now.Add(time.Second)
+	limiter.Allow("open", "new")
+	require.Len(t, limiter.buckets, 1)
+}
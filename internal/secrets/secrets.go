@@ -0,0 +1,115 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/blakewilliams/remote-development-manager/internal/handler"
+	"github.com/blakewilliams/remote-development-manager/internal/hostservice/confirm"
+)
+
+// Rule detects a kind of secret.
+type Rule struct {
+	// Name describes the secret, e.g. "GitHub token".
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// DefaultRules detect commonly copied credentials.
+var DefaultRules = []Rule{
+	{Name: "AWS access key", Pattern: regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{Name: "GitHub token", Pattern: regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{Name: "private key", Pattern: regexp.MustCompile(`-----BEGIN ([A-Z0-9]+ )*PRIVATE KEY( BLOCK)?-----`)},
+}
+
+// Scan returns the names of the rules matching content.
+func Scan(rules []Rule, content string) []string {
+	var found []string
+	for _, rule := range rules {
+		if rule.Pattern.MatchString(content) {
+			found = append(found, rule.Name)
+		}
+	}
+
+	return found
+}
+
+// Mode is what happens when clipboard contents look like a secret.
+type Mode string
+
+const (
+	// ModeOff disables scanning.
+	ModeOff Mode = "off"
+	// ModeWarn allows the contents and logs a warning.
+	ModeWarn Mode = "warn"
+	// ModeConfirm asks the user on the host to allow the contents.
+	ModeConfirm Mode = "confirm"
+	// ModeBlock rejects the contents.
+	ModeBlock Mode = "block"
+)
+
+// ParseMode returns the mode with the given name, defaulting to ModeWarn.
+func ParseMode(name string) (Mode, error) {
+	switch Mode(name) {
+	case "":
+		return ModeWarn, nil
+	case ModeOff, ModeWarn, ModeConfirm, ModeBlock:
+		return Mode(name), nil
+	default:
+		return ModeWarn, fmt.Errorf("unknown secrets mode %q, expected off, warn, confirm or block", name)
+	}
+}
+
+// Guard checks clipboard contents moving between the host and remote machines
+// for secrets.
+type Guard struct {
+	Rules   []Rule
+	Mode    Mode
+	Confirm confirm.Func
+}
+
+// Check returns the kinds of secrets found in content and an error with
+// handler.CodeDenied if the contents may not be transferred. action describes
+// the transfer for confirmation dialogs, e.g. "copy a value to the
+// clipboard".
+func (g *Guard) Check(ctx context.Context, content string, client string, action string) ([]string, error) {
+	if g.Mode == ModeOff {
+		return nil, nil
+	}
+
+	found := Scan(g.Rules, content)
+	if len(found) == 0 {
+		return nil, nil
+	}
+
+	kinds := strings.Join(found, ", ")
+
+	switch g.Mode {
+	case ModeBlock:
+		return found, handler.Errorf(handler.CodeDenied, "clipboard contents look like they contain a secret (%s) and were blocked", kinds)
+	case ModeConfirm:
+		message := fmt.Sprintf("%s wants to %s, which looks like it contains a secret (%s). Allow?", client, action, kinds)
+		allowed, err := g.Confirm(ctx, message)
+		if err != nil {
+			return found, err
+		}
+		if !allowed {
+			return found, handler.Errorf(handler.CodeDenied, "clipboard contents look like they contain a secret (%s) and were denied", kinds)
+		}
+	}
+
+	return found, nil
+}
+
+// Publishable reports whether content may be pushed to remote machines
+// without a request, such as in clipboard events, where there's no one to
+// confirm it.
+func (g *Guard) Publishable(content string) bool {
+	if g.Mode == ModeOff || g.Mode == ModeWarn {
+		return true
+	}
+
+	return len(Scan(g.Rules, content)) == 0
+}
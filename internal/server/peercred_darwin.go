@@ -0,0 +1,34 @@
+//go:build darwin
+// +build darwin
+
+package server
+
+import (
+	"net"
+
+	"github.com/blakewilliams/remote-development-manager/internal/handler"
+	"golang.org/x/sys/unix"
+)
+
+// peerCredentials returns the process on the other end of a unix socket
+// connection using LOCAL_PEERCRED, as getpeereid does, and LOCAL_PEERPID.
+func peerCredentials(conn net.Conn) (*handler.Peer, error) {
+	var cred *unix.Xucred
+	var pid int
+	var credErr error
+
+	err := rawFd(conn, func(fd uintptr) {
+		cred, credErr = unix.GetsockoptXucred(int(fd), unix.SOL_LOCAL, unix.LOCAL_PEERCRED)
+		if credErr == nil {
+			pid, credErr = unix.GetsockoptInt(int(fd), unix.SOL_LOCAL, unix.LOCAL_PEERPID)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if credErr != nil {
+		return nil, credErr
+	}
+
+	return &handler.Peer{PID: pid, UID: int(cred.Uid)}, nil
+}
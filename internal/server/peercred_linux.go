@@ -0,0 +1,30 @@
+//go:build linux
+// +build linux
+
+package server
+
+import (
+	"net"
+
+	"github.com/blakewilliams/remote-development-manager/internal/handler"
+	"golang.org/x/sys/unix"
+)
+
+// peerCredentials returns the process on the other end of a unix socket
+// connection using SO_PEERCRED.
+func peerCredentials(conn net.Conn) (*handler.Peer, error) {
+	var cred *unix.Ucred
+	var credErr error
+
+	err := rawFd(conn, func(fd uintptr) {
+		cred, credErr = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if credErr != nil {
+		return nil, credErr
+	}
+
+	return &handler.Peer{PID: int(cred.Pid), UID: int(cred.Uid)}, nil
+}
@@ -0,0 +1,32 @@
+//go:build linux
+// +build linux
+
+package server
+
+import (
+	"net"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+func TestPeerCredentials(t *testing.T) {
+	fds, err := unix.Socketpair(unix.AF_UNIX, unix.SOCK_STREAM, 0)
+	require.NoError(t, err)
+
+	local := os.NewFile(uintptr(fds[0]), "local")
+	defer local.Close()
+	remote := os.NewFile(uintptr(fds[1]), "remote")
+	defer remote.Close()
+
+	conn, err := net.FileConn(local)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	peer, err := peerCredentials(conn)
+	require.NoError(t, err)
+	require.Equal(t, os.Getpid(), peer.PID)
+	require.Equal(t, os.Getuid(), peer.UID)
+}
@@ -0,0 +1,235 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/blakewilliams/remote-development-manager/internal/client"
+	"github.com/blakewilliams/remote-development-manager/internal/handler"
+)
+
+// routes returns the handler for the versioned endpoints, which allow tools
+// like curl to integrate without the Go client.
+func (s *Server) routes() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	mux.Handle("/v1/status", methods{
+		http.MethodGet: s.getStatus,
+	})
+	mux.Handle("/v1/capabilities", methods{
+		http.MethodGet: s.getCapabilities,
+	})
+	mux.Handle("/v1/clipboard", methods{
+		http.MethodGet:  s.getClipboard,
+		http.MethodPost: s.postClipboard,
+	})
+	mux.Handle("/v1/open", methods{
+		http.MethodPost: s.postOpen,
+	})
+	mux.Handle("/v1/sessions", methods{
+		http.MethodGet: s.getSessions,
+	})
+	mux.Handle("/v1/events", methods{
+		http.MethodGet: s.getEvents,
+	})
+
+	return mux
+}
+
+// methods dispatches a request to the handler registered for its HTTP method,
+// responding with 405 Method Not Allowed when there is none.
+type methods map[string]http.HandlerFunc
+
+func (m methods) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if handler, ok := m[r.Method]; ok {
+		handler(rw, r)
+		return
+	}
+
+	allowed := make([]string, 0, len(m))
+	for method := range m {
+		allowed = append(allowed, method)
+	}
+	sort.Strings(allowed)
+	rw.Header().Set("Allow", strings.Join(allowed, ", "))
+	writeError(rw, r, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+}
+
+func (s *Server) getStatus(rw http.ResponseWriter, r *http.Request) {
+	s.writeJSON(rw, map[string]interface{}{
+		"status":  "running",
+		"version": client.ProtocolVersion,
+	})
+}
+
+func (s *Server) getCapabilities(rw http.ResponseWriter, r *http.Request) {
+	s.writeJSON(rw, s.capabilities())
+}
+
+func (s *Server) getSessions(rw http.ResponseWriter, r *http.Request) {
+	s.writeJSON(rw, s.sessions.List())
+}
+
+// clipboardBody is the JSON representation of the clipboard contents. TTL
+// optionally clears copied contents after a duration, e.g. "30s".
+type clipboardBody struct {
+	Content string `json:"content"`
+	TTL     string `json:"ttl,omitempty"`
+}
+
+func (s *Server) getClipboard(rw http.ResponseWriter, r *http.Request) {
+	req := &handler.Request{Name: "paste", Identity: identityFromHeaders(r)}
+	s.extendWriteDeadline(rw, r, req)
+	contents, err := s.dispatch(r.Context(), req)
+	if err != nil {
+		writeHandlerError(rw, r, err)
+		return
+	}
+
+	if acceptsJSON(r) {
+		s.writeJSON(rw, clipboardBody{Content: string(contents)})
+		return
+	}
+
+	rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if _, err := rw.Write(contents); err != nil {
+		s.log(r.Context()).Warn("could not write paste response", "error", err)
+	}
+}
+
+func (s *Server) postClipboard(rw http.ResponseWriter, r *http.Request) {
+	var body clipboardBody
+	if err := decodeBody(r, &body.Content, &body); err != nil {
+		writeHandlerError(rw, r, err)
+		return
+	}
+
+	// Raw bodies can set the ttl in the query string.
+	if body.TTL == "" {
+		body.TTL = r.URL.Query().Get("ttl")
+	}
+
+	req := &handler.Request{
+		Name:      "copy",
+		Arguments: []string{body.Content},
+		Identity:  identityFromHeaders(r),
+	}
+	if body.TTL != "" {
+		req.Options = map[string]string{"ttl": body.TTL}
+	}
+
+	s.extendWriteDeadline(rw, r, req)
+	_, err := s.dispatch(r.Context(), req)
+	if err != nil {
+		writeHandlerError(rw, r, err)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// openBody is the JSON representation of an open request.
+type openBody struct {
+	Target string `json:"target"`
+}
+
+func (s *Server) postOpen(rw http.ResponseWriter, r *http.Request) {
+	var body openBody
+	if err := decodeBody(r, &body.Target, &body); err != nil {
+		writeHandlerError(rw, r, err)
+		return
+	}
+
+	body.Target = strings.TrimSpace(body.Target)
+	if body.Target == "" {
+		writeHandlerError(rw, r, handler.Errorf(handler.CodeInvalid, "target is required"))
+		return
+	}
+
+	req := &handler.Request{
+		Name:      "open",
+		Arguments: []string{body.Target},
+		Identity:  identityFromHeaders(r),
+	}
+	s.extendWriteDeadline(rw, r, req)
+	_, err := s.dispatch(r.Context(), req)
+	if err != nil {
+		writeHandlerError(rw, r, err)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// identityFromHeaders returns the client identity sent in the X-Rdm-* request
+// headers.
+func identityFromHeaders(r *http.Request) client.Identity {
+	return client.Identity{
+		Hostname:  r.Header.Get("X-Rdm-Hostname"),
+		User:      r.Header.Get("X-Rdm-User"),
+		SessionID: r.Header.Get("X-Rdm-Session"),
+		Label:     r.Header.Get("X-Rdm-Label"),
+	}
+}
+
+// decodeBody reads the request body into value when it is JSON, otherwise the
+// raw body is stored in text.
+func decodeBody(r *http.Request, text *string, value interface{}) error {
+	defer r.Body.Close()
+
+	contents, err := io.ReadAll(r.Body)
+	if err != nil {
+		return handler.Errorf(handler.CodeInvalid, "could not read request body: %w", err)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/json" {
+		*text = string(contents)
+		return nil
+	}
+
+	if err := json.Unmarshal(contents, value); err != nil {
+		return handler.Errorf(handler.CodeInvalid, "could not parse request body: %w", err)
+	}
+
+	return nil
+}
+
+// acceptsJSON returns true if the client prefers a JSON response.
+func acceptsJSON(r *http.Request) bool {
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, _ := mime.ParseMediaType(strings.TrimSpace(accept))
+		switch mediaType {
+		case "application/json":
+			return true
+		case "text/plain":
+			return false
+		}
+	}
+
+	return false
+}
+
+// writeHandlerError responds with an error returned by a handler, using a
+// status matching its code.
+func writeHandlerError(rw http.ResponseWriter, r *http.Request, err error) {
+	writeError(rw, r, statusForCode(handler.CodeOf(err)), err)
+}
+
+// writeError responds with the error as JSON or plain text depending on what
+// the client accepts.
+func writeError(rw http.ResponseWriter, r *http.Request, status int, err error) {
+	if acceptsJSON(r) {
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(status)
+		json.NewEncoder(rw).Encode(map[string]string{"error": err.Error(), "code": string(handler.CodeOf(err))})
+		return
+	}
+
+	http.Error(rw, err.Error(), status)
+}
@@ -2,67 +2,452 @@ package server
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"log"
 	"net"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/blakewilliams/remote-development-manager/internal/audit"
 	"github.com/blakewilliams/remote-development-manager/internal/client"
+	"github.com/blakewilliams/remote-development-manager/internal/events"
+	"github.com/blakewilliams/remote-development-manager/internal/expiry"
+	"github.com/blakewilliams/remote-development-manager/internal/handler"
+	"github.com/blakewilliams/remote-development-manager/internal/handler/builtin"
 	"github.com/blakewilliams/remote-development-manager/internal/hostservice"
+	"github.com/blakewilliams/remote-development-manager/internal/logging"
+	"github.com/blakewilliams/remote-development-manager/internal/ratelimit"
+	"github.com/blakewilliams/remote-development-manager/internal/secrets"
+	"github.com/blakewilliams/remote-development-manager/internal/session"
 )
 
 type Server struct {
 	host       hostservice.Runner
 	path       string
-	logger     *log.Logger
+	logger     *logging.Logger
+	registry   *handler.Registry
+	sessions   *session.Tracker
+	bus        *events.Bus
+	watcher    *clipboardWatcher
+	auditLog   *audit.Log
+	limiter    *ratelimit.Limiter
+	timeouts   map[string]time.Duration
+	guard      *secrets.Guard
+	clearAfter time.Duration
+	clearer    *expiry.Clearer
 	httpServer *http.Server
+	mux        *http.ServeMux
 	cancel     context.CancelFunc
 }
 
 func (s *Server) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	requestID, err := newRequestID()
+	if err != nil {
+		s.logger.Error("could not generate request id", "error", err)
+	}
+	rw.Header().Set("X-Request-Id", requestID)
+	r = r.WithContext(logging.NewContext(r.Context(), s.logger.With("request_id", requestID)))
+
+	// The Go client includes the socket path in the request URL, which
+	// ServeMux would redirect to a cleaned path. Only versioned routes go
+	// through the mux.
+	if strings.HasPrefix(r.URL.Path, "/v1/") {
+		s.mux.ServeHTTP(rw, r)
+		return
+	}
+
+	s.serveLegacy(rw, r)
+}
+
+// serveLegacy handles commands POSTed as JSON to any unversioned path. This is
+// the endpoint used by the Go client and is kept for compatibility with older
+// clients.
+func (s *Server) serveLegacy(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		rw.Header().Set("Allow", http.MethodPost)
+		writeError(rw, r, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		s.logger.Printf("could not read request body: %v", err)
+		s.log(r.Context()).Warn("could not read request body", "error", err)
 	}
 	r.Body.Close()
 
 	var command client.Command
 	json.Unmarshal(body, &command)
 
-	switch command.Name {
-	case "status":
-		rw.Write([]byte(`{ "status": "running" }`))
-	case "copy":
-		err := s.host.Copy(command.Arguments[0])
-		if err != nil {
-			s.logger.Printf("error running copy command: %v", err)
+	req := &handler.Request{
+		Name:      command.Name,
+		Arguments: command.Arguments,
+		Options:   command.Options,
+	}
+	if command.Client != nil {
+		req.Identity = *command.Client
+	}
+
+	s.extendWriteDeadline(rw, r, req)
+	contents, err := s.dispatch(r.Context(), req)
+	if err != nil {
+		s.writeCommandError(rw, command, err)
+		return
+	}
+
+	if _, err := rw.Write(contents); err != nil {
+		s.log(r.Context()).Warn("could not write response", "command", command.Name, "error", err)
+	}
+}
+
+// dispatch runs the handler registered for the request, recording the session
+// that sent it and logging any error. Only valid requests for registered
+// commands are recorded as session activity.
+func (s *Server) dispatch(ctx context.Context, req *handler.Request) ([]byte, error) {
+	if req.Peer == nil {
+		req.Peer = peerFromContext(ctx)
+	}
+
+	if h, ok := s.registry.Lookup(req.Name); ok && h.Validate(req) == nil {
+		s.sessions.Touch(req.Identity, req.Name)
+	}
+	logger := s.log(ctx).With("command", req.Name, "client", req.Identity, "session", req.Identity.SessionID)
+	if req.Peer != nil {
+		logger = logger.With("pid", req.Peer.PID, "uid", req.Peer.UID)
+	}
+	logger.Info("running command")
+
+	if err := s.rateLimit(req); err != nil {
+		logger.Warn("suppressed rate limited command", "error", err)
+		s.audit(ctx, req, nil, err)
+		return nil, err
+	}
+
+	copiedSecret, err := s.checkSecrets(ctx, logger, req, handler.DirectionToHost, nil)
+	if err != nil {
+		s.audit(ctx, req, nil, err)
+		return nil, err
+	}
+
+	start := time.Now()
+	contents, err := s.run(ctx, req)
+	duration := time.Since(start).Round(time.Microsecond)
+
+	// An explicit ttl takes precedence over clearing secrets.
+	if err == nil && copiedSecret != "" && s.clearAfter > 0 && req.Options["ttl"] == "" {
+		s.clearer.ClearAfter(copiedSecret, s.clearAfter)
+	}
+	if err == nil {
+		if _, err = s.checkSecrets(ctx, logger, req, handler.DirectionFromHost, contents); err != nil {
+			contents = nil
 		}
-	case "open":
-		err := s.host.Open(command.Arguments[0])
-		if err != nil {
-			s.logger.Printf("error running open command: %v", err)
+	}
+
+	switch code := handler.CodeOf(err); {
+	case err == nil:
+		logger.Debug("command finished", "duration", duration, "response_bytes", len(contents))
+	case code == handler.CodeFailed:
+		logger.Error("command failed", "code", code, "error", err, "duration", duration)
+	default:
+		logger.Warn("command failed", "code", code, "error", err, "duration", duration)
+	}
+
+	s.audit(ctx, req, contents, err)
+
+	return contents, err
+}
+
+// run dispatches req, cancelling the handler's context once the command's
+// timeout passes. Commands without a timeout run until the client gives up.
+func (s *Server) run(ctx context.Context, req *handler.Request) ([]byte, error) {
+	timeout, ok := s.timeouts[req.Name]
+	if !ok {
+		return s.registry.Dispatch(ctx, req)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	contents, err := s.registry.Dispatch(ctx, req)
+	// Handlers report killed commands in different ways, so the context is
+	// checked rather than err.
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, handler.Errorf(handler.CodeTimeout, "%s timed out after %s", req.Name, timeout)
+	}
+
+	return contents, err
+}
+
+// extendWriteDeadline lifts the server's write timeout for requests that may
+// wait on the user at the host: long running handlers and clipboard commands
+// the secret guard asks to confirm. Other commands with a timeout longer than
+// the write timeout may respond until theirs has passed.
+func (s *Server) extendWriteDeadline(rw http.ResponseWriter, r *http.Request, req *handler.Request) {
+	h, ok := s.registry.Lookup(req.Name)
+	if !ok {
+		return
+	}
+
+	var deadline time.Time
+	confirm := s.guard != nil && s.guard.Mode == secrets.ModeConfirm && h.Clipboard != handler.DirectionNone
+	if !h.LongRunning && !confirm {
+		timeout, ok := s.timeouts[req.Name]
+		if !ok || timeout <= s.httpServer.WriteTimeout {
+			return
 		}
-	case "stop":
-		s.logger.Printf("received stop command")
-		s.cancel()
-	case "paste":
-		contents, err := s.host.Paste()
-		if err != nil {
-			s.logger.Printf("error running paste command: %v", err)
-		} else {
-			_, err := rw.Write(contents)
-			if err != nil {
-				s.logger.Printf("could not write paste message: %v", err)
+		deadline = time.Now().Add(timeout + s.httpServer.WriteTimeout)
+	}
+
+	if err := http.NewResponseController(rw).SetWriteDeadline(deadline); err != nil {
+		s.log(r.Context()).Warn("could not extend the write timeout", "command", req.Name, "error", err)
+	}
+}
+
+// SetTimeouts limits how long each command may run, keyed by command name.
+// Commands without a timeout are not limited.
+func (s *Server) SetTimeouts(timeouts map[string]time.Duration) {
+	s.timeouts = timeouts
+}
+
+// SetAuditLog records every command run on behalf of clients, other than those
+// only reporting on the server itself, to log.
+func (s *Server) SetAuditLog(log *audit.Log) {
+	s.auditLog = log
+}
+
+// SetSecretGuard checks clipboard contents moving between clients and the
+// host for secrets. Secrets copied to the host are cleared from the clipboard
+// after clearAfter, unless it is zero.
+func (s *Server) SetSecretGuard(guard *secrets.Guard, clearAfter time.Duration) {
+	s.guard = guard
+	s.clearAfter = clearAfter
+	s.watcher.publishable = guard.Publishable
+}
+
+// checkSecrets checks the clipboard contents of requests for handlers moving
+// contents in direction, returning the contents if they look like a secret.
+// response is the handler's response for contents returned to the client.
+func (s *Server) checkSecrets(ctx context.Context, logger *logging.Logger, req *handler.Request, direction handler.Direction, response []byte) (string, error) {
+	if s.guard == nil {
+		return "", nil
+	}
+
+	h, ok := s.registry.Lookup(req.Name)
+	if !ok || h.Clipboard != direction || h.Content == nil || h.Validate(req) != nil {
+		return "", nil
+	}
+
+	action := "copy a value to the clipboard"
+	if direction == handler.DirectionFromHost {
+		action = "paste the clipboard"
+	}
+
+	content := h.Content(req, response)
+	found, err := s.guard.Check(ctx, content, req.Identity.String(), action)
+	if len(found) == 0 {
+		return "", err
+	}
+
+	logger.Warn("clipboard contents look like a secret", "kinds", strings.Join(found, ","), "mode", s.guard.Mode, "allowed", err == nil)
+	return content, err
+}
+
+// SetRateLimiter limits how often each session may run commands. Without a
+// limiter commands are never limited.
+func (s *Server) SetRateLimiter(limiter *ratelimit.Limiter) {
+	s.limiter = limiter
+}
+
+// rateLimit returns an error if the session sending req has run the command
+// too often. Requests without a session ID share a bucket.
+func (s *Server) rateLimit(req *handler.Request) error {
+	if s.limiter == nil {
+		return nil
+	}
+
+	allowed, wait := s.limiter.Allow(req.Name, req.Identity.SessionID)
+	if allowed {
+		return nil
+	}
+
+	// Round up so clients retrying after the wait are allowed.
+	step := 100 * time.Millisecond
+	wait = (wait + step - 1) / step * step
+
+	return handler.Errorf(handler.CodeRateLimited, "%s is rate limited, try again in %s", req.Name, wait)
+}
+
+// audit writes a record of the request to the audit log, if there is one.
+// Requests for unknown commands are recorded too.
+func (s *Server) audit(ctx context.Context, req *handler.Request, contents []byte, err error) {
+	if s.auditLog == nil {
+		return
+	}
+
+	record := audit.Record{
+		Time:          time.Now(),
+		Client:        req.Identity,
+		Peer:          req.Peer,
+		Command:       req.Name,
+		Result:        "ok",
+		ResponseBytes: len(contents),
+	}
+	if err != nil {
+		record.Result = string(handler.CodeOf(err))
+		record.Error = err.Error()
+	}
+
+	// Arguments are only described once they are known to be valid.
+	if h, ok := s.registry.Lookup(req.Name); ok {
+		if h.Unaudited {
+			return
+		}
+
+		if h.Validate(req) == nil {
+			if h.Target != nil {
+				record.Target = h.Target(req)
+			}
+			if h.Content != nil && err == nil {
+				record.Content = h.Content(req, contents)
 			}
 		}
+	}
+
+	s.writeAudit(ctx, record)
+}
+
+// writeAudit appends record to the audit log, if there is one.
+func (s *Server) writeAudit(ctx context.Context, record audit.Record) {
+	if s.auditLog == nil {
+		return
+	}
+
+	if err := s.auditLog.Write(record); err != nil {
+		s.log(ctx).Error("could not write audit log", "error", err)
+	}
+}
+
+// log returns the request scoped logger stored in ctx by ServeHTTP.
+func (s *Server) log(ctx context.Context) *logging.Logger {
+	return logging.FromContext(ctx, s.logger)
+}
+
+// newRequestID returns a random ID used to correlate the log entries of a
+// request.
+func newRequestID() (string, error) {
+	id := make([]byte, 6)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(id), nil
+}
+
+// Register makes an additional command available to clients.
+func (s *Server) Register(h *handler.Handler) error {
+	return s.registry.Register(h)
+}
+
+// capabilities returns the protocol version and commands this server
+// supports.
+func (s *Server) capabilities() client.Capabilities {
+	commands := s.registry.Capabilities()
+	// Events are streamed from their own endpoint rather than a handler.
+	commands["events"] = []string{}
+
+	return client.Capabilities{
+		Version:  client.ProtocolVersion,
+		Commands: commands,
+	}
+}
+
+// registerServerHandlers registers the commands that operate on the server
+// itself rather than the host system.
+func (s *Server) registerServerHandlers() error {
+	handlers := []*handler.Handler{
+		{
+			Name:      "capabilities",
+			Unaudited: true,
+			Run: func(ctx context.Context, req *handler.Request) ([]byte, error) {
+				return json.Marshal(s.capabilities())
+			},
+		},
+		{
+			Name:      "status",
+			Unaudited: true,
+			Run: func(ctx context.Context, req *handler.Request) ([]byte, error) {
+				return []byte(`{ "status": "running" }`), nil
+			},
+		},
+		{
+			Name:      "sessions",
+			Unaudited: true,
+			Run: func(ctx context.Context, req *handler.Request) ([]byte, error) {
+				return json.Marshal(s.sessions.List())
+			},
+		},
+		{
+			Name: "stop",
+			Run: func(ctx context.Context, req *handler.Request) ([]byte, error) {
+				s.log(ctx).Info("received stop command")
+				s.cancel()
+				return nil, nil
+			},
+		},
+	}
+
+	for _, h := range handlers {
+		if err := s.registry.Register(h); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// statusForCode maps handler error codes to HTTP statuses.
+func statusForCode(code handler.Code) int {
+	switch code {
+	case handler.CodeNotFound:
+		return http.StatusNotFound
+	case handler.CodeInvalid:
+		return http.StatusBadRequest
+	case handler.CodeDenied:
+		return http.StatusForbidden
+	case handler.CodeRateLimited:
+		return http.StatusTooManyRequests
+	case handler.CodeTimeout:
+		return http.StatusGatewayTimeout
 	default:
-		s.logger.Printf("command not found: %s", command.Name)
+		return http.StatusInternalServerError
+	}
+}
+
+// writeCommandError responds with an error status and message. Legacy clients
+// do not check the status code and would treat the message as command output,
+// so they receive an empty response instead.
+func (s *Server) writeCommandError(rw http.ResponseWriter, command client.Command, err error) {
+	if command.Version < 1 {
+		return
+	}
+
+	code := handler.CodeOf(err)
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(statusForCode(code))
+	s.writeJSON(rw, map[string]string{"error": err.Error(), "code": string(code)})
+}
+
+func (s *Server) writeJSON(rw http.ResponseWriter, value interface{}) {
+	rw.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(rw).Encode(value); err != nil {
+		s.logger.Warn("could not write response", "error", err)
 	}
 }
 
@@ -70,8 +455,16 @@ func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
 	ctx, cancel := context.WithCancel(ctx)
 	s.cancel = cancel
 
+	// Derive request contexts from ctx so long-lived requests, like event
+	// streams, end when the server is stopped.
+	s.httpServer.BaseContext = func(net.Listener) context.Context {
+		return ctx
+	}
+
+	go s.watcher.poll(ctx)
+
 	go func() {
-		s.logger.Printf("HTTP server listening on %s", s.path)
+		s.logger.Info("HTTP server listening", "path", s.path)
 		err := s.httpServer.Serve(listener)
 		if err != nil {
 			cancel()
@@ -79,26 +472,43 @@ func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
 	}()
 
 	<-ctx.Done()
-	err := s.httpServer.Shutdown(ctx)
+
+	// ctx is already done, so give in-flight requests a short grace period
+	// using a fresh context.
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer shutdownCancel()
+
+	err := s.httpServer.Shutdown(shutdownCtx)
+
+	// Expiring values shouldn't outlive the server.
+	s.clearer.Flush()
+
 	if err != nil {
-		s.logger.Printf("HTTP server shutdown (err=%v)", err)
-	} else {
-		s.logger.Println("HTTP server shutdown (clean)")
+		s.logger.Error("HTTP server shutdown", "error", err)
+		return err
 	}
-	return err
+
+	s.logger.Info("HTTP server shutdown (clean)")
+	return ctx.Err()
 }
 
+// Listen serves on the unix socket at the server's path, only accepting
+// connections from processes run by the current user.
 func (s *Server) Listen(ctx context.Context) error {
+	if err := prepareSocketDir(filepath.Dir(s.path)); err != nil {
+		return err
+	}
+
 	sock, err := net.Listen("unix", s.path)
 	if err != nil {
 		var errNo syscall.Errno
 
 		if errors.As(err, &errNo) && errNo == syscall.EADDRINUSE {
-			c := client.NewWithSocketPath(s.path)
+			c := client.NewWithTransport(client.Transport{Network: client.NetworkUnix, Address: s.path})
 
-			_, err := c.SendCommand(ctx, "status")
+			_, statusErr := c.SendCommand(ctx, "status")
 
-			if err != nil {
+			if statusErr != nil {
 				os.Remove(s.path)
 				sock, err = net.Listen("unix", s.path)
 			} else {
@@ -106,22 +516,53 @@ func (s *Server) Listen(ctx context.Context) error {
 			}
 		}
 	}
+	if err != nil {
+		return fmt.Errorf("could not listen to unix socket: %w", err)
+	}
 	defer os.Remove(s.path)
 
-	return s.Serve(ctx, sock)
+	if err := os.Chmod(s.path, 0600); err != nil {
+		sock.Close()
+		return fmt.Errorf("could not restrict unix socket: %w", err)
+	}
+
+	return s.Serve(ctx, &peerListener{Listener: sock, uid: os.Getuid(), logger: s.logger})
 }
 
-func New(path string, service hostservice.Runner, logger *log.Logger) *Server {
+func New(path string, service hostservice.Runner, logger *logging.Logger) *Server {
+	bus := events.NewBus()
+	watcher := &clipboardWatcher{bus: bus, paster: service.Paste}
+	host := &watchedHost{Runner: service, watcher: watcher}
+
 	server := &Server{
-		host:   service,
-		path:   path,
-		logger: logger,
+		host:     host,
+		path:     path,
+		logger:   logger,
+		registry: handler.NewRegistry(),
+		sessions: session.NewTracker(),
+		bus:      bus,
+		watcher:  watcher,
+		clearer:  expiry.New(host, logger),
 	}
+
+	// Registration only fails on duplicate names, which would be a
+	// programming error for the built-in handlers.
+	if err := server.registerServerHandlers(); err != nil {
+		panic(err)
+	}
+	if err := builtin.Register(server.registry, host, server.clearer); err != nil {
+		panic(err)
+	}
+
+	server.mux = server.routes()
+	// Event streams and long running commands lift the write timeout for
+	// their own response.
 	server.httpServer = &http.Server{
 		Handler:      server,
 		ReadTimeout:  time.Second * 10,
 		WriteTimeout: time.Second * 10,
-		ErrorLog:     logger,
+		ErrorLog:     logger.StdLogger(logging.LevelError),
+		ConnContext:  connContext,
 	}
 
 	return server
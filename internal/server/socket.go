@@ -0,0 +1,113 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+
+	"github.com/blakewilliams/remote-development-manager/internal/handler"
+	"github.com/blakewilliams/remote-development-manager/internal/logging"
+)
+
+// prepareSocketDir creates the directory holding the socket, ensuring only the
+// current user can access it. An existing directory owned by another user is
+// rejected, since they could replace the socket.
+func prepareSocketDir(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("could not create socket directory %s: %w", dir, err)
+	}
+
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return fmt.Errorf("could not create socket directory %s: %w", dir, err)
+	}
+
+	if !info.IsDir() {
+		return fmt.Errorf("socket directory %s is not a directory", dir)
+	}
+
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok && int(stat.Uid) != os.Getuid() {
+		return fmt.Errorf("socket directory %s is owned by another user", dir)
+	}
+
+	if info.Mode().Perm() != 0700 {
+		if err := os.Chmod(dir, 0700); err != nil {
+			return fmt.Errorf("could not restrict socket directory %s: %w", dir, err)
+		}
+	}
+
+	return nil
+}
+
+// peerListener only accepts unix socket connections from processes running as
+// uid, closing any others. Accepted connections carry the peer's credentials.
+type peerListener struct {
+	net.Listener
+	uid    int
+	logger *logging.Logger
+}
+
+func (l *peerListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+
+		peer, err := peerCredentials(conn)
+		if err != nil {
+			l.logger.Warn("rejected connection", "error", err)
+			conn.Close()
+			continue
+		}
+
+		if peer.UID != l.uid {
+			l.logger.Warn("rejected connection from another user", "uid", peer.UID, "pid", peer.PID)
+			conn.Close()
+			continue
+		}
+
+		return &peerConn{Conn: conn, peer: peer}, nil
+	}
+}
+
+// peerConn is a connection accepted by peerListener.
+type peerConn struct {
+	net.Conn
+	peer *handler.Peer
+}
+
+type peerContextKey struct{}
+
+// connContext stores the credentials of the peer in the context of requests on
+// the connection.
+func connContext(ctx context.Context, conn net.Conn) context.Context {
+	if conn, ok := conn.(*peerConn); ok {
+		return context.WithValue(ctx, peerContextKey{}, conn.peer)
+	}
+
+	return ctx
+}
+
+// peerFromContext returns the credentials stored by connContext, if any.
+func peerFromContext(ctx context.Context) *handler.Peer {
+	peer, _ := ctx.Value(peerContextKey{}).(*handler.Peer)
+	return peer
+}
+
+// rawFd calls fn with the file descriptor of a unix socket connection.
+func rawFd(conn net.Conn, fn func(fd uintptr)) error {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return fmt.Errorf("connection is not a unix socket")
+	}
+
+	raw, err := unixConn.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	return raw.Control(fn)
+}
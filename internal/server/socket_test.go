@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/blakewilliams/remote-development-manager/internal/client"
+	"github.com/blakewilliams/remote-development-manager/internal/handler"
+	"github.com/blakewilliams/remote-development-manager/internal/logging"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrepareSocketDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "rdm")
+
+	require.NoError(t, prepareSocketDir(dir))
+	info, err := os.Stat(dir)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0700), info.Mode().Perm())
+
+	require.NoError(t, os.Chmod(dir, 0755))
+	require.NoError(t, prepareSocketDir(dir))
+	info, err = os.Stat(dir)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0700), info.Mode().Perm())
+
+	file := filepath.Join(dir, "file")
+	require.NoError(t, os.WriteFile(file, nil, 0600))
+	require.Error(t, prepareSocketDir(file))
+}
+
+func TestPeerListener(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rdm.sock")
+
+	testCases := map[string]struct {
+		uid      int
+		accepted bool
+	}{
+		"same user":    {uid: os.Getuid(), accepted: true},
+		"another user": {uid: os.Getuid() + 1, accepted: false},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			sock, err := net.Listen("unix", path)
+			require.NoError(t, err)
+
+			listener := &peerListener{Listener: sock, uid: tc.uid, logger: logging.Discard()}
+			defer listener.Close()
+
+			accepted := make(chan net.Conn, 1)
+			go func() {
+				conn, err := listener.Accept()
+				if err == nil {
+					accepted <- conn
+				}
+			}()
+
+			conn, err := net.Dial("unix", path)
+			require.NoError(t, err)
+			defer conn.Close()
+
+			if tc.accepted {
+				select {
+				case conn := <-accepted:
+					conn.Close()
+				case <-time.After(time.Second):
+					t.Fatal("connection was not accepted")
+				}
+				return
+			}
+
+			// Rejected connections are closed by the server.
+			conn.SetReadDeadline(time.Now().Add(time.Second))
+			_, err = conn.Read(make([]byte, 1))
+			require.Error(t, err)
+			require.False(t, isTimeout(err))
+			require.Len(t, accepted, 0)
+		})
+	}
+}
+
+func isTimeout(err error) bool {
+	netErr, ok := err.(net.Error)
+	return ok && netErr.Timeout()
+}
+
+func TestServer_Peer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rdm.sock")
+	server := New(path, newTestHostService(), logging.Discard())
+
+	require.NoError(t, server.Register(&handler.Handler{
+		Name: "whoami",
+		Run: func(ctx context.Context, req *handler.Request) ([]byte, error) {
+			return json.Marshal(req.Peer)
+		},
+	}))
+
+	sock, err := net.Listen("unix", path)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go server.Serve(ctx, &peerListener{Listener: sock, uid: os.Getuid(), logger: logging.Discard()})
+
+	result, err := client.NewWithSocketPath(path).SendCommand(ctx, "whoami")
+	require.NoError(t, err)
+
+	var peer handler.Peer
+	require.NoError(t, json.Unmarshal(result, &peer))
+	require.Equal(t, os.Getpid(), peer.PID)
+	require.Equal(t, os.Getuid(), peer.UID)
+}
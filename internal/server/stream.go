@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/blakewilliams/remote-development-manager/internal/audit"
+	"github.com/blakewilliams/remote-development-manager/internal/client"
+	"github.com/blakewilliams/remote-development-manager/internal/events"
+	"github.com/blakewilliams/remote-development-manager/internal/handler"
+)
+
+// heartbeatInterval is how often a comment is sent on idle event streams so
+// that forwarded connections are not closed for inactivity.
+const heartbeatInterval = 30 * time.Second
+
+// getEvents streams events to the client using server-sent events until the
+// client disconnects or the server stops. The optional "types" query parameter
+// limits the stream to a comma separated list of event types.
+//
+// Since events carry clipboard contents, the subscription and every event
+// delivered are recorded in the audit log like commands are.
+func (s *Server) getEvents(rw http.ResponseWriter, r *http.Request) {
+	flusher, ok := rw.(http.Flusher)
+	if !ok {
+		writeError(rw, r, http.StatusInternalServerError, errors.New("streaming is not supported"))
+		return
+	}
+
+	// Streams stay open indefinitely, so they're exempt from the server's
+	// write timeout.
+	if err := http.NewResponseController(rw).SetWriteDeadline(time.Time{}); err != nil {
+		s.log(r.Context()).Warn("could not lift the write timeout of an event stream", "error", err)
+	}
+
+	types := map[string]bool{}
+	for _, eventType := range strings.Split(r.URL.Query().Get("types"), ",") {
+		if eventType != "" {
+			types[eventType] = true
+		}
+	}
+
+	identity := identityFromHeaders(r)
+	peer := peerFromContext(r.Context())
+	s.sessions.Touch(identity, "events")
+	s.log(r.Context()).Info("streaming events", "client", identity, "session", identity.SessionID)
+
+	subscribed := "all events"
+	if len(types) > 0 {
+		subscribed = strings.Join(sortedKeys(types), ",")
+	}
+	s.writeAudit(r.Context(), audit.Record{
+		Time:    time.Now(),
+		Client:  identity,
+		Peer:    peer,
+		Command: "events",
+		Target:  "subscribed to " + subscribed,
+		Result:  "ok",
+	})
+
+	subscription, unsubscribe := s.bus.Subscribe()
+	defer unsubscribe()
+
+	rw.Header().Set("Content-Type", "text/event-stream")
+	rw.Header().Set("Cache-Control", "no-cache")
+	rw.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(rw, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case event := <-subscription:
+			if len(types) > 0 && !types[event.Type] {
+				continue
+			}
+
+			data, err := json.Marshal(event)
+			if err != nil {
+				s.log(r.Context()).Error("could not encode event", "type", event.Type, "error", err)
+				continue
+			}
+
+			if _, err := fmt.Fprintf(rw, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+				return
+			}
+			s.auditEvent(r.Context(), identity, peer, event, len(data))
+		}
+
+		flusher.Flush()
+	}
+}
+
+// auditEvent records an event delivered to a client in the audit log. The
+// contents of clipboard events are only recorded when explicitly configured.
+func (s *Server) auditEvent(ctx context.Context, identity client.Identity, peer *handler.Peer, event events.Event, size int) {
+	record := audit.Record{
+		Time:          time.Now(),
+		Client:        identity,
+		Peer:          peer,
+		Command:       "events",
+		Target:        "delivered " + event.Type + " event",
+		Result:        "ok",
+		ResponseBytes: size,
+	}
+
+	if event.Type == events.TypeClipboard {
+		var data events.ClipboardData
+		if err := json.Unmarshal(event.Data, &data); err == nil {
+			record.Target = fmt.Sprintf("delivered clipboard event, %d bytes", len(data.Content))
+			record.Content = data.Content
+		}
+	}
+
+	s.writeAudit(ctx, record)
+}
+
+// sortedKeys returns the keys of set in order.
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
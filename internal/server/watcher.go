@@ -0,0 +1,97 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/blakewilliams/remote-development-manager/internal/events"
+	"github.com/blakewilliams/remote-development-manager/internal/hostservice"
+)
+
+// clipboardPollInterval is how often the host clipboard is checked for changes
+// while clients are subscribed to events.
+const clipboardPollInterval = time.Second
+
+// clipboardPollTimeout bounds each read of the host clipboard while polling.
+const clipboardPollTimeout = 5 * time.Second
+
+// clipboardWatcher publishes an event whenever the host clipboard changes,
+// whether it was changed by a client or by the user on the host.
+type clipboardWatcher struct {
+	mu     sync.Mutex
+	last   []byte
+	known  bool
+	bus    *events.Bus
+	paster func(ctx context.Context) ([]byte, error)
+	// publishable filters the contents published to subscribers. All
+	// contents are published when it is nil.
+	publishable func(content string) bool
+}
+
+// observe records the current clipboard contents, publishing an event if they
+// differ from the last contents seen. The first observation only establishes a
+// baseline.
+func (w *clipboardWatcher) observe(contents []byte) {
+	w.mu.Lock()
+	changed := w.known && !bytes.Equal(w.last, contents)
+	w.last = append([]byte{}, contents...)
+	w.known = true
+	w.mu.Unlock()
+
+	if !changed || (w.publishable != nil && !w.publishable(string(contents))) {
+		return
+	}
+
+	event, err := events.New(events.TypeClipboard, events.ClipboardData{Content: string(contents)})
+	if err != nil {
+		return
+	}
+	w.bus.Publish(event)
+}
+
+// poll checks the host clipboard until ctx is done. The clipboard is only read
+// while there are subscribers.
+func (w *clipboardWatcher) poll(ctx context.Context) {
+	ticker := time.NewTicker(clipboardPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if w.bus.Subscribers() == 0 {
+				w.mu.Lock()
+				w.known = false
+				w.mu.Unlock()
+				continue
+			}
+
+			pasteCtx, cancel := context.WithTimeout(ctx, clipboardPollTimeout)
+			contents, err := w.paster(pasteCtx)
+			cancel()
+			if err != nil {
+				continue
+			}
+			w.observe(contents)
+		}
+	}
+}
+
+// watchedHost notifies the clipboard watcher of copies made through the
+// server so subscribers see them without waiting for the next poll.
+type watchedHost struct {
+	hostservice.Runner
+	watcher *clipboardWatcher
+}
+
+func (h *watchedHost) Copy(ctx context.Context, s string) error {
+	if err := h.Runner.Copy(ctx, s); err != nil {
+		return err
+	}
+
+	h.watcher.observe([]byte(s))
+	return nil
+}
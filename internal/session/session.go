@@ -0,0 +1,91 @@
+package session
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/blakewilliams/remote-development-manager/internal/client"
+)
+
+// Expiry is how long a session may be idle before it is forgotten.
+const Expiry = 24 * time.Hour
+
+// Session is a remote client that has sent commands to the server.
+type Session struct {
+	client.Identity
+	FirstSeen   time.Time `json:"first_seen"`
+	LastSeen    time.Time `json:"last_seen"`
+	LastCommand string    `json:"last_command"`
+	Requests    int       `json:"requests"`
+}
+
+// Tracker records the sessions that have sent commands to the server. It is
+// safe for concurrent use.
+type Tracker struct {
+	mu       sync.Mutex
+	sessions map[string]*Session
+	now      func() time.Time
+}
+
+// NewTracker returns an empty Tracker.
+func NewTracker() *Tracker {
+	return &Tracker{
+		sessions: make(map[string]*Session),
+		now:      time.Now,
+	}
+}
+
+// Touch records a command sent by the given identity. Clients that do not send
+// an identity are not tracked.
+func (t *Tracker) Touch(identity client.Identity, command string) {
+	if identity.SessionID == "" {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	t.expire(now)
+
+	session, ok := t.sessions[identity.SessionID]
+	if !ok {
+		session = &Session{FirstSeen: now}
+		t.sessions[identity.SessionID] = session
+	}
+
+	session.Identity = identity
+	session.LastSeen = now
+	session.LastCommand = command
+	session.Requests++
+}
+
+// List returns the active sessions, most recently seen first.
+func (t *Tracker) List() []Session {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.expire(t.now())
+
+	sessions := make([]Session, 0, len(t.sessions))
+	for _, session := range t.sessions {
+		sessions = append(sessions, *session)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeen.After(sessions[j].LastSeen)
+	})
+
+	return sessions
+}
+
+// expire removes sessions that have been idle longer than Expiry. The caller
+// must hold t.mu.
+func (t *Tracker) expire(now time.Time) {
+	for id, session := range t.sessions {
+		if now.Sub(session.LastSeen) > Expiry {
+			delete(t.sessions, id)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package session
+
+import (
+	"testing"
+	"time"
+
+	"github.com/blakewilliams/remote-development-manager/internal/client"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracker(t *testing.T) {
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+	tracker := NewTracker()
+	tracker.now = func() time.Time { return now }
+
+	foo := client.Identity{Hostname: "foo", User: "blake", SessionID: "1", Label: "codespace-foo"}
+	bar := client.Identity{Hostname: "bar", User: "blake", SessionID: "2"}
+
+	tracker.Touch(foo, "copy")
+	now = now.Add(time.Minute)
+	tracker.Touch(bar, "open")
+	now = now.Add(time.Minute)
+	tracker.Touch(foo, "paste")
+	tracker.Touch(client.Identity{}, "paste")
+
+	sessions := tracker.List()
+	require.Len(t, sessions, 2)
+
+	require.Equal(t, "codespace-foo", sessions[0].String())
+	require.Equal(t, "paste", sessions[0].LastCommand)
+	require.Equal(t, 2, sessions[0].Requests)
+	require.Equal(t, "blake@bar", sessions[1].String())
+
+	now = now.Add(Expiry)
+	sessions = tracker.List()
+	require.Len(t, sessions, 1)
+	require.Equal(t, "1", sessions[0].SessionID)
+}
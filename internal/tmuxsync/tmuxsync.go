@@ -0,0 +1,169 @@
+package tmuxsync
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Buffers reads and writes tmux paste buffers.
+type Buffers interface {
+	// Latest returns the most recently added buffer.
+	Latest(ctx context.Context) (string, error)
+	// Add adds a new buffer, making it the most recent.
+	Add(ctx context.Context, content string) error
+}
+
+// CopyFunc sends content to the host clipboard.
+type CopyFunc func(ctx context.Context, content string) error
+
+// Syncer keeps the most recent tmux buffer and the host clipboard in sync.
+//
+// To prevent a value from bouncing back and forth, the last value synced in
+// either direction is remembered: a tmux buffer that was just received from
+// the host is not pushed back, and a clipboard event for a value that was just
+// pushed is not added to tmux again.
+type Syncer struct {
+	buffers Buffers
+	copy    CopyFunc
+
+	mu    sync.Mutex
+	last  string
+	known bool
+	// pushing is the value being pushed to the host, whose clipboard event
+	// may arrive before the push returns.
+	pushing   string
+	isPushing bool
+}
+
+// New returns a Syncer that reads and writes the given buffers and pushes new
+// buffers to the host using copy.
+func New(buffers Buffers, copy CopyFunc) *Syncer {
+	return &Syncer{
+		buffers: buffers,
+		copy:    copy,
+	}
+}
+
+// PollTmux pushes the latest tmux buffer to the host if it changed since the
+// last sync. The first poll only records the current buffer so existing
+// buffers are not pushed on startup.
+func (s *Syncer) PollTmux(ctx context.Context) error {
+	content, err := s.buffers.Latest(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	if !s.known {
+		s.last = content
+		s.known = true
+		s.mu.Unlock()
+		return nil
+	}
+
+	if content == s.last {
+		s.mu.Unlock()
+		return nil
+	}
+
+	// The lock isn't held while pushing so a slow host doesn't block values
+	// arriving from it.
+	previous := s.last
+	s.pushing, s.isPushing = content, true
+	s.mu.Unlock()
+
+	err = s.copy(ctx, content)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pushing, s.isPushing = "", false
+
+	if err != nil {
+		return fmt.Errorf("could not push tmux buffer to host: %w", err)
+	}
+
+	// A value received from the host during the push is newer, so it's kept.
+	if s.last == previous {
+		s.last = content
+	}
+
+	return nil
+}
+
+// FromHost adds the host clipboard contents as a tmux buffer unless it is the
+// value that was last synced.
+func (s *Syncer) FromHost(ctx context.Context, content string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.known && (content == s.last || s.isPushing && content == s.pushing) {
+		return nil
+	}
+
+	if err := s.buffers.Add(ctx, content); err != nil {
+		return fmt.Errorf("could not add tmux buffer: %w", err)
+	}
+	s.last = content
+	s.known = true
+
+	return nil
+}
+
+// Poll calls PollTmux every interval until ctx is done, reporting errors to
+// onError.
+func (s *Syncer) Poll(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.PollTmux(ctx); err != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// Tmux implements Buffers using the tmux command.
+type Tmux struct{}
+
+// Latest returns the most recently added buffer, or an empty string if there
+// are no buffers.
+func (Tmux) Latest(ctx context.Context) (string, error) {
+	cmd := exec.CommandContext(ctx, "tmux", "list-buffers", "-F", "#{buffer_name}")
+	names, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("could not list tmux buffers: %w", err)
+	}
+
+	if strings.TrimSpace(string(names)) == "" {
+		return "", nil
+	}
+
+	cmd = exec.CommandContext(ctx, "tmux", "show-buffer")
+	content, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("could not read tmux buffer: %w", err)
+	}
+
+	return string(content), nil
+}
+
+// Add loads content into a new tmux buffer.
+func (Tmux) Add(ctx context.Context, content string) error {
+	cmd := exec.CommandContext(ctx, "tmux", "load-buffer", "-")
+	cmd.Stdin = strings.NewReader(content)
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("could not run tmux load-buffer: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package tmuxsync
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeBuffers struct {
+	buffers []string
+}
+
+func (f *fakeBuffers) Latest(ctx context.Context) (string, error) {
+	if len(f.buffers) == 0 {
+		return "", nil
+	}
+
+	return f.buffers[len(f.buffers)-1], nil
+}
+
+func (f *fakeBuffers) Add(ctx context.Context, content string) error {
+	f.buffers = append(f.buffers, content)
+	return nil
+}
+
+func TestSyncer(t *testing.T) {
+	ctx := context.Background()
+	buffers := &fakeBuffers{buffers: []string{"existing"}}
+
+	var copied []string
+	syncer := New(buffers, func(ctx context.Context, content string) error {
+		copied = append(copied, content)
+		return nil
+	})
+
+	// Existing buffers are not pushed on startup.
+	require.NoError(t, syncer.PollTmux(ctx))
+	require.Empty(t, copied)
+
+	// New tmux buffers are pushed to the host once.
+	buffers.Add(ctx, "from tmux")
+	require.NoError(t, syncer.PollTmux(ctx))
+	require.NoError(t, syncer.PollTmux(ctx))
+	require.Equal(t, []string{"from tmux"}, copied)
+
+	// The host echoing the pushed value back is ignored.
+	require.NoError(t, syncer.FromHost(ctx, "from tmux"))
+	require.Equal(t, []string{"existing", "from tmux"}, buffers.buffers)
+
+	// Host clipboard changes become tmux buffers and are not pushed back.
+	require.NoError(t, syncer.FromHost(ctx, "from host"))
+	require.Equal(t, []string{"existing", "from tmux", "from host"}, buffers.buffers)
+	require.NoError(t, syncer.PollTmux(ctx))
+	require.Equal(t, []string{"from tmux"}, copied)
+}
+
+func TestSyncer_FromHostDuringPush(t *testing.T) {
+	ctx := context.Background()
+	buffers := &fakeBuffers{}
+
+	pushing := make(chan struct{})
+	release := make(chan struct{})
+	syncer := New(buffers, func(ctx context.Context, content string) error {
+		close(pushing)
+		<-release
+		return nil
+	})
+
+	require.NoError(t, syncer.PollTmux(ctx))
+	buffers.Add(ctx, "from tmux")
+
+	errs := make(chan error, 1)
+	go func() { errs <- syncer.PollTmux(ctx) }()
+	<-pushing
+
+	// Values from the host aren't blocked by a push in flight, and the
+	// pushed value echoing back isn't added to tmux again.
+	require.NoError(t, syncer.FromHost(ctx, "from tmux"))
+	require.NoError(t, syncer.FromHost(ctx, "from host"))
+	require.Equal(t, []string{"from tmux", "from host"}, buffers.buffers)
+
+	close(release)
+	require.NoError(t, <-errs)
+
+	// The newer host value is remembered, so it isn't pushed back.
+	require.NoError(t, syncer.FromHost(ctx, "from host"))
+	require.Equal(t, []string{"from tmux", "from host"}, buffers.buffers)
+}
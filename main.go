@@ -30,10 +30,10 @@ func main() {
 		}
 	}()
 
-	err := cmd.Execute(ctx, userMessages)
+	code := cmd.Execute(ctx, userMessages)
 
-	if err != nil {
-		userMessages.Printf("error executing command: %v", err)
-		os.Exit(1)
+	if code != cmd.ExitOK {
+		cancel()
+		os.Exit(code)
 	}
 }